@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNotTerminal is returned when an interactive prompt is requested without a terminal
+var ErrNotTerminal = errors.New("stdin is not a terminal")
+
+// PromptForAPIKey reads an API key from the terminal, echoing '*' for each character
+func (c *CLI) PromptForAPIKey() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNotTerminal
+	}
+
+	fmt.Fprint(c.writer, "No API key found. Enter API key: ")
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("error reading input: %w", err)
+	}
+	defer term.Restore(fd, oldState)
+
+	var key []byte
+	for {
+		b, err := c.reader.ReadByte()
+		if err != nil {
+			fmt.Fprint(c.writer, "\r\n")
+			return "", fmt.Errorf("error reading input: %w", err)
+		}
+
+		switch b {
+		case '\r', '\n':
+			fmt.Fprint(c.writer, "\r\n")
+			apiKey := strings.TrimSpace(string(key))
+			if apiKey == "" {
+				return "", errors.New("no API key entered")
+			}
+			return apiKey, nil
+		case 3: // Ctrl+C
+			fmt.Fprint(c.writer, "\r\n")
+			return "", io.EOF
+		case 127, '\b': // Backspace
+			if len(key) > 0 {
+				key = key[:len(key)-1]
+				fmt.Fprint(c.writer, "\b \b")
+			}
+		default:
+			if b >= ' ' {
+				key = append(key, b)
+				fmt.Fprint(c.writer, "*")
+			}
+		}
+	}
+}
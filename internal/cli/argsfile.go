@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// ParseFlagsFromFile parses command-line flags stored in a response file. Arguments are
+// separated by whitespace and may be quoted with single or double quotes; lines starting
+// with '#' are comments.
+func (c *CLI) ParseFlagsFromFile(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read flags file: %w", err)
+	}
+
+	args, err := splitArgs(string(content))
+	if err != nil {
+		return fmt.Errorf("invalid flags file %s: %w", path, err)
+	}
+	return flag.CommandLine.Parse(args)
+}
+
+// splitArgs splits s into arguments on whitespace, honoring quotes and comment lines
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '"' && r == '\\' && i+1 < len(runes) && (runes[i+1] == '"' || runes[i+1] == '\\'):
+			i++
+			current.WriteRune(runes[i])
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		case r == '#' && !inArg && (i == 0 || runes[i-1] == '\n'):
+			// Skip the comment up to the end of the line
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
@@ -6,38 +6,278 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strconv"
 	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// RunMode identifies what the program does after startup
+type RunMode int
+
+const (
+	// InteractiveMode runs a conversation reading messages from the terminal
+	InteractiveMode RunMode = iota
+	// StdinMode reads a single message from stdin and exits after the response
+	StdinMode
+	// ModelInfoMode displays information about the model and exits
+	ModelInfoMode
+	// ModelfileMode prints the Modelfile of a model and exits
+	ModelfileMode
+	// ModelSizeMode prints the disk size of the model and exits
+	ModelSizeMode
+	// VRAMUsageMode prints the VRAM used by the loaded model and exits
+	VRAMUsageMode
+	// PushModelMode pushes a local model to the Ollama registry and exits
+	PushModelMode
+	// ListProfilesMode lists the profiles defined in the config file and exits
+	ListProfilesMode
+	// InitMode writes a .env template in the current directory and exits
+	InitMode
+)
+
+// Output formats selected with --output
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+	OutputYAML = "yaml"
 )
 
+// defaultPromptPrefix is the prompt shown before each message in interactive mode
+const defaultPromptPrefix = "Enter your message (or '/quit' to exit): "
+
 // CLI handles command-line interface operations
 type CLI struct {
 	hideThinking     bool
+	thinkStartTag    string
+	thinkEndTag      string
 	model            string
 	temperature      float64
+	topP             float64
+	presencePenalty  float64
+	frequencyPenalty float64
+	seed             *int64
 	outputJson       bool
+	output           string
 	showModelInfo    bool
 	systemPromptFile string
 	pullModel        bool
+	configFile       string
+	profile          string
+	listProfiles     bool
+	modelfile        string
+	interactive      bool
+	showModelSize    bool
+	rawCompletion    bool
+	noStream         bool
+	streamDelay      time.Duration
+	requestTimeout   time.Duration
+	pushModel        string
+	maxResponseLines int
+	watchPrompt      bool
+	reasoningEffort  string
+	quiet            bool
+	verbose          bool
+	includeUsage     bool
+	noTotalUsage     bool
+	noTokenUsage     bool
+	inputEncoding    string
+	conversationID   string
+	numCtx           int
+	maxTokens        int
+	stopSequences    stringList
+	costThreshold    float64
+	appendSuffix     string
+	prependPrefix    string
+	responseSchema   string
+	logFile          string
+	resumeID         string
+	parallelTools    bool
+	noParallelTools  bool
+	vramUsage        bool
+	userAgent        string
+	messageTemplate  string
+	export           string
+	metricsAddr      string
+	streamBuffer     int
+	promptPrefix     string
+	sessionReport    bool
+	initEnv          bool
+	conversationFile string
+	exportMarkdown   string
+	force            bool
 	reader           *bufio.Reader
+	writer           io.Writer
+	errWriter        io.Writer
 }
 
 // NewCLI creates a new CLI instance
 func NewCLI() *CLI {
 	return &CLI{
-		reader: bufio.NewReader(os.Stdin),
+		reader:       bufio.NewReader(os.Stdin),
+		writer:       os.Stdout,
+		errWriter:    os.Stderr,
+		promptPrefix: defaultPromptPrefix,
 	}
 }
 
-// ParseFlags parses command-line flags
-func (c *CLI) ParseFlags() {
+// ParseFlags parses the command-line flags in args, usually os.Args[1:]
+func (c *CLI) ParseFlags(args []string) {
 	flag.BoolVar(&c.hideThinking, "hide-thinking", false, "Hide thinking/reasoning parts of the response")
+	flag.StringVar(&c.thinkStartTag, "think-start", "", "Tag starting a thinking block, e.g. <reasoning> or [THINK] (default <think>)")
+	flag.StringVar(&c.thinkEndTag, "think-end", "", "Tag ending a thinking block, e.g. </reasoning> or [/THINK] (default </think>)")
 	flag.StringVar(&c.model, "model", "", "Model to use for completions")
 	flag.Float64Var(&c.temperature, "temperature", 0.0, "Temperature for completions (0.0-2.0)")
-	flag.BoolVar(&c.outputJson, "json", false, "Output response as JSON")
+	flag.Float64Var(&c.topP, "top-p", 0.0, "Top-p (nucleus) sampling for completions (0.0-1.0, 0 = API default)")
+	flag.StringVar(&c.output, "output", OutputText, "Output format: text, json or yaml")
+	flag.Float64Var(&c.presencePenalty, "presence-penalty", 0.0, "Penalty for tokens already present in the text (-2.0-2.0, 0 = API default)")
+	flag.Float64Var(&c.frequencyPenalty, "frequency-penalty", 0.0, "Penalty for tokens by their frequency in the text (-2.0-2.0, 0 = API default)")
+	flag.Func("seed", "Seed for reproducible completions (default unset)", func(value string) error {
+		seed, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+		c.seed = &seed
+		return nil
+	})
+	flag.BoolVar(&c.outputJson, "json", false, "Output response as JSON (deprecated, use --output json)")
 	flag.BoolVar(&c.showModelInfo, "model-info", false, "Display detailed model information")
 	flag.StringVar(&c.systemPromptFile, "system-prompt", "", "File containing system prompt (optional)")
 	flag.BoolVar(&c.pullModel, "pull", false, "Pull the model specified by --model if not available")
-	flag.Parse()
+	flag.StringVar(&c.configFile, "config", "", "YAML config file (default: <user config dir>/llm-go/config.yaml)")
+	flag.StringVar(&c.profile, "profile", "", "Named profile from the config file to use")
+	flag.BoolVar(&c.listProfiles, "list-profiles", false, "List the profiles defined in the config file")
+	flag.StringVar(&c.modelfile, "modelfile", "", "Print the Modelfile of the given model and exit")
+	flag.BoolVar(&c.interactive, "interactive", true, "Run an interactive conversation; when false, read a single message from stdin")
+	flag.BoolVar(&c.showModelSize, "model-size", false, "Display the disk size of the model and exit")
+	flag.BoolVar(&c.vramUsage, "vram-usage", false, "Display the VRAM used by the loaded model and exit")
+	flag.BoolVar(&c.rawCompletion, "raw-completion", false, "Use Ollama raw text completion (/api/generate) instead of chat")
+	flag.BoolVar(&c.noStream, "no-stream", false, "Get each response from a single non-streaming request")
+	flag.DurationVar(&c.streamDelay, "stream-delay", 0, "Delay between displayed chunks, e.g. 20ms (1ms-1s, default disabled)")
+	flag.DurationVar(&c.requestTimeout, "timeout", 0, "Deadline of each request, e.g. 5m (default 120s)")
+	flag.StringVar(&c.pushModel, "push-model", "", "Push the given local model to the Ollama registry and exit")
+	flag.IntVar(&c.maxResponseLines, "max-response-lines", 0, "Truncate the displayed response after N lines (0 = unlimited)")
+	flag.BoolVar(&c.watchPrompt, "watch-system-prompt", false, "Reload the --system-prompt file when it changes")
+	flag.StringVar(&c.reasoningEffort, "reasoning-effort", "", "Reasoning effort for o1/o3/o4 models: low, medium or high")
+	flag.BoolVar(&c.quiet, "quiet", !c.AutodetectTTY(), "Only output the response text (no prompts, headers or statistics; default when stdout is not a terminal)")
+	flag.BoolVar(&c.verbose, "verbose", false, "Log retries of rate limited and failed requests to stderr")
+	flag.BoolVar(&c.includeUsage, "include-usage-in-response", false, "Include the usage reported by each streaming chunk in JSON output")
+	flag.BoolVar(&c.noTotalUsage, "no-total-usage", false, "Do not show the total token usage when the session ends")
+	flag.BoolVar(&c.noTokenUsage, "no-token-usage", false, "Do not show the token usage after each response")
+	flag.StringVar(&c.inputEncoding, "input-encoding", "utf-8", "Encoding of stdin input, e.g. cp1252 or iso-8859-1 (system prompt files must be UTF-8)")
+	flag.StringVar(&c.conversationID, "conversation-id", "", "Identifier included in JSON output for correlation (default: random UUID)")
+	flag.IntVar(&c.numCtx, "num-ctx", 0, "Override the Ollama model context window in tokens (0 = model default; needs enough VRAM)")
+	flag.IntVar(&c.maxTokens, "max-tokens", 0, "Maximum number of tokens per completion (0 = API default)")
+	flag.Var(&c.stopSequences, "stop", "Sequence ending the completion, repeatable (at most 4)")
+	flag.Float64Var(&c.costThreshold, "cost-warning-threshold", 0, "Ask for confirmation before requests estimated to cost more than this many USD (0 = never)")
+	flag.StringVar(&c.appendSuffix, "append-suffix", "", "Text appended on a new line to every user message, e.g. \"Be concise.\"")
+	flag.StringVar(&c.prependPrefix, "prepend-prefix", "", "Text prepended on a separate line to every user message")
+	flag.StringVar(&c.responseSchema, "response-schema", "", "JSON schema file the response must follow (structured outputs, needs a compatible model)")
+	flag.StringVar(&c.logFile, "log-file", "", "Append every message to this JSONL audit log")
+	flag.StringVar(&c.resumeID, "conversation-resume", "", "Resume the conversation with this ID from the --log-file audit log")
+	flag.BoolVar(&c.parallelTools, "parallel-tool-calls", false, "Allow the model to request several tool calls at once")
+	flag.BoolVar(&c.noParallelTools, "no-parallel-tool-calls", false, "Ask the model to request at most one tool call at a time")
+	flag.StringVar(&c.userAgent, "user-agent", "", "User-Agent header sent with every HTTP request")
+	flag.StringVar(&c.messageTemplate, "template", "", "Template file wrapping each user message, with {{.Message}} as placeholder")
+	flag.StringVar(&c.export, "conversation-export", "", "Write the conversation on exit as <format>=<file> (json, markdown, html, text)")
+	flag.StringVar(&c.metricsAddr, "metrics-addr", "", "Serve expvar metrics on /metrics and /debug/vars at this address (e.g. :9090)")
+	flag.IntVar(&c.streamBuffer, "stream-buffer", 0, "Number of response chunks buffered ahead of the terminal (default 0 = unbuffered)")
+	flag.StringVar(&c.promptPrefix, "prompt-prefix", c.promptPrefix, "Prompt shown before each message in interactive mode")
+	flag.BoolVar(&c.sessionReport, "conversation-stats", false, "Print a session report (turns, tokens, time, cost) to stderr on exit")
+	flag.BoolVar(&c.initEnv, "init", false, "Write a commented .env template in the current directory and exit")
+	flag.BoolVar(&c.force, "force", false, "Overwrite an existing .env with --init")
+	flag.StringVar(&c.conversationFile, "conversation-file", "", "JSON file the conversation is loaded from at startup and saved to on exit")
+	flag.StringVar(&c.exportMarkdown, "export-md", "", "Write the conversation as Markdown to this file on exit (also the default file of /export)")
+	c.parseArgs(args)
+
+	// --json is a deprecated alias of --output json
+	if c.outputJson && c.output == OutputText {
+		c.output = OutputJSON
+	}
+
+	// A resumed conversation keeps logging under its own ID
+	if c.conversationID == "" {
+		c.conversationID = c.resumeID
+	}
+	if c.conversationID == "" {
+		c.conversationID = newConversationID()
+	}
+}
+
+// parseArgs parses the arguments, reading the flags from a response file first when
+// the first argument is @file
+func (c *CLI) parseArgs(args []string) {
+	if len(args) == 0 || !strings.HasPrefix(args[0], "@") {
+		_ = flag.CommandLine.Parse(args) // exits on error
+		return
+	}
+
+	if err := c.ParseFlagsFromFile(args[0][1:]); err != nil {
+		fmt.Fprintf(c.errWriter, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	// Flags after @file override the ones from the file
+	_ = flag.CommandLine.Parse(args[1:])
+}
+
+// AutodetectTTY reports whether stdout is a terminal, as opposed to a pipe or file
+func (c *CLI) AutodetectTTY() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// ValidateFlags checks that flag values are within their allowed ranges and sets up
+// the decoding of stdin input
+func (c *CLI) ValidateFlags() error {
+	if c.streamDelay != 0 && (c.streamDelay < time.Millisecond || c.streamDelay > time.Second) {
+		return fmt.Errorf("invalid --stream-delay %v: must be between 1ms and 1s", c.streamDelay)
+	}
+	if c.requestTimeout < 0 {
+		return fmt.Errorf("invalid --timeout %v: must not be negative", c.requestTimeout)
+	}
+	if c.maxResponseLines < 0 {
+		return fmt.Errorf("invalid --max-response-lines %d: must not be negative", c.maxResponseLines)
+	}
+	if c.watchPrompt && c.systemPromptFile == "" {
+		return fmt.Errorf("--watch-system-prompt requires --system-prompt")
+	}
+	if c.streamBuffer < 0 {
+		return fmt.Errorf("invalid --stream-buffer %d: must not be negative", c.streamBuffer)
+	}
+	if c.numCtx < 0 {
+		return fmt.Errorf("invalid --num-ctx %d: must not be negative", c.numCtx)
+	}
+	if c.costThreshold < 0 {
+		return fmt.Errorf("invalid --cost-warning-threshold %v: must not be negative", c.costThreshold)
+	}
+	if c.resumeID != "" && c.logFile == "" {
+		return fmt.Errorf("--conversation-resume requires --log-file")
+	}
+	if c.export != "" {
+		if _, _, err := ParseKeyValue(c.export, "="); err != nil {
+			return fmt.Errorf("invalid --conversation-export: %w", err)
+		}
+	}
+	if c.parallelTools && c.noParallelTools {
+		return fmt.Errorf("--parallel-tool-calls and --no-parallel-tool-calls cannot be combined")
+	}
+	switch c.output {
+	case OutputText, OutputJSON, OutputYAML:
+	default:
+		return fmt.Errorf("invalid --output '%s': must be text, json or yaml", c.output)
+	}
+	if c.outputJson && c.output != OutputJSON {
+		return fmt.Errorf("--json cannot be combined with --output %s", c.output)
+	}
+	if c.includeUsage && !c.IsStructuredOutput() {
+		return fmt.Errorf("--include-usage-in-response requires --output json or yaml")
+	}
+	switch c.reasoningEffort {
+	case "", "low", "medium", "high":
+	default:
+		return fmt.Errorf("invalid --reasoning-effort '%s': must be low, medium or high", c.reasoningEffort)
+	}
+	return c.applyInputEncoding(c.inputEncoding)
 }
 
 // GetHideThinking returns the hide-thinking flag value
@@ -45,6 +285,16 @@ func (c *CLI) GetHideThinking() bool {
 	return c.hideThinking
 }
 
+// GetThinkStartTag returns the think-start flag value
+func (c *CLI) GetThinkStartTag() string {
+	return c.thinkStartTag
+}
+
+// GetThinkEndTag returns the think-end flag value
+func (c *CLI) GetThinkEndTag() string {
+	return c.thinkEndTag
+}
+
 // GetModel returns the model flag value
 func (c *CLI) GetModel() string {
 	return c.model
@@ -55,9 +305,40 @@ func (c *CLI) GetTemperature() float64 {
 	return c.temperature
 }
 
-// GetJSON returns the json flag value
+// GetTopP returns the top-p flag value
+func (c *CLI) GetTopP() float64 {
+	return c.topP
+}
+
+// GetPresencePenalty returns the presence-penalty flag value
+func (c *CLI) GetPresencePenalty() float64 {
+	return c.presencePenalty
+}
+
+// GetFrequencyPenalty returns the frequency-penalty flag value
+func (c *CLI) GetFrequencyPenalty() float64 {
+	return c.frequencyPenalty
+}
+
+// GetSeed returns the seed flag value, or nil when --seed is not set
+func (c *CLI) GetSeed() *int64 {
+	return c.seed
+}
+
+// GetJSON reports whether the output format is JSON
 func (c *CLI) GetJSON() bool {
-	return c.outputJson
+	return c.output == OutputJSON
+}
+
+// GetOutput returns the output format: OutputText, OutputJSON or OutputYAML
+func (c *CLI) GetOutput() string {
+	return c.output
+}
+
+// IsStructuredOutput reports whether responses are output as JSON or YAML documents
+// instead of streamed text
+func (c *CLI) IsStructuredOutput() bool {
+	return c.output == OutputJSON || c.output == OutputYAML
 }
 
 // GetSystemPromptFile returns the system prompt file path
@@ -67,14 +348,23 @@ func (c *CLI) GetSystemPromptFile() string {
 
 // ShowUsage displays usage information
 func (c *CLI) ShowUsage() {
-	fmt.Println("Usage: llm-go [options]")
-	fmt.Println("Options:")
+	fmt.Fprintln(c.writer, "Usage: llm-go [options]")
+	fmt.Fprintln(c.writer, "Options:")
+	flag.CommandLine.SetOutput(c.writer)
 	flag.PrintDefaults()
-	fmt.Println("\nEnvironment Variables:")
-	fmt.Println("  OPENAI_API_KEY      API key for OpenAI-compatible API")
-	fmt.Println("  OPENAI_BASE_URL     Base URL for OpenAI-compatible API (default: https://api.openai.com/v1)")
-	fmt.Println("  OPENAI_MODEL        Model to use for completions (default: gpt-4o)")
-	fmt.Println("  OPENAI_TEMPERATURE  Temperature for completions (0.0-2.0, default: 0.7)")
+	fmt.Fprintln(c.writer, "\nEnvironment Variables:")
+	fmt.Fprintln(c.writer, "  OPENAI_API_KEY      API key for OpenAI-compatible API")
+	fmt.Fprintln(c.writer, "  OPENAI_BASE_URL     Base URL for OpenAI-compatible API (default: https://api.openai.com/v1)")
+	fmt.Fprintln(c.writer, "  OPENAI_MODEL        Model to use for completions (default: gpt-4o)")
+	fmt.Fprintln(c.writer, "  OPENAI_TEMPERATURE  Temperature for completions (0.0-2.0, default: 0.7)")
+	fmt.Fprintln(c.writer, "  OPENAI_TOP_P        Top-p (nucleus) sampling for completions (0.0-1.0, default: API default)")
+	fmt.Fprintln(c.writer, "  OPENAI_PRESENCE_PENALTY   Presence penalty for completions (-2.0-2.0)")
+	fmt.Fprintln(c.writer, "  OPENAI_FREQUENCY_PENALTY  Frequency penalty for completions (-2.0-2.0)")
+	fmt.Fprintln(c.writer, "  OPENAI_SEED         Seed for reproducible completions")
+	fmt.Fprintln(c.writer, "  OPENAI_STOP         Comma-separated sequences ending the completion")
+	fmt.Fprintln(c.writer, "  LLM_REQUEST_TIMEOUT Deadline of each request (default: 120s)")
+	fmt.Fprintln(c.writer, "  GOOGLE_API_KEY      API key for Google Gemini, used when OPENAI_API_KEY is not set")
+	fmt.Fprintln(c.writer, "  GOOGLE_MODEL        Gemini model to use (default: gemini-2.0-flash)")
 }
 
 // GetUserInput gets input from the user
@@ -86,18 +376,45 @@ func (c *CLI) GetUserInput() (string, error) {
 	return strings.TrimSpace(message), nil
 }
 
+// Confirm asks a yes/no question and returns true only when the user answers yes
+func (c *CLI) Confirm(question string) (bool, error) {
+	fmt.Fprintf(c.writer, "%s [y/N]: ", question)
+	answer, err := c.GetUserInput()
+	if err != nil {
+		return false, err
+	}
+	answer = strings.ToLower(answer)
+	return answer == "y" || answer == "yes", nil
+}
+
 // ReadFromStdin reads all input from stdin
 func (c *CLI) ReadFromStdin() (string, error) {
-	data, err := io.ReadAll(os.Stdin)
+	data, err := io.ReadAll(c.reader)
 	if err != nil {
 		return string(data), fmt.Errorf("error reading input: %w", err)
 	}
 	return strings.TrimSpace(string(data)), nil
 }
 
-// ShowError displays an error message
+// ShowError displays an error message on the error writer, keeping it out of the response output
 func (c *CLI) ShowError(err error) {
-	fmt.Printf("Error: %v\n", err)
+	fmt.Fprintf(c.errWriter, "Error: %v\n", err)
+}
+
+// ShowPrompt displays the prompt asking for the next message
+func (c *CLI) ShowPrompt() {
+	fmt.Fprint(c.writer, "\n"+c.promptPrefix)
+}
+
+// SetPromptPrefix replaces the prompt shown before each message. When called before
+// ParseFlags it becomes the default of --prompt-prefix.
+func (c *CLI) SetPromptPrefix(prefix string) {
+	c.promptPrefix = prefix
+}
+
+// ShowStatus displays a status message on the error writer, keeping it out of the response output
+func (c *CLI) ShowStatus(msg string) {
+	fmt.Fprintln(c.errWriter, msg)
 }
 
 // ShouldQuit checks if the user wants to quit
@@ -105,6 +422,16 @@ func (c *CLI) ShouldQuit(message string) bool {
 	return message == "/quit"
 }
 
+// ParseCommand splits an in-conversation command such as "/export chat.md" into its name
+// and argument. It reports false when the message is not a command.
+func (c *CLI) ParseCommand(message string) (name, arg string, ok bool) {
+	if !strings.HasPrefix(message, "/") {
+		return "", "", false
+	}
+	name, arg, _ = strings.Cut(message[1:], " ")
+	return name, strings.TrimSpace(arg), name != ""
+}
+
 // IsValidMessage checks if the message is valid (not empty)
 func (c *CLI) IsValidMessage(message string) bool {
 	return message != ""
@@ -119,3 +446,303 @@ func (c *CLI) GetShowModelInfo() bool {
 func (c *CLI) GetPullModel() bool {
 	return c.pullModel
 }
+
+// GetConfigFile returns the config file path
+func (c *CLI) GetConfigFile() string {
+	return c.configFile
+}
+
+// GetProfile returns the profile flag value
+func (c *CLI) GetProfile() string {
+	return c.profile
+}
+
+// GetListProfiles returns the list-profiles flag value
+func (c *CLI) GetListProfiles() bool {
+	return c.listProfiles
+}
+
+// GetModelfile returns the model whose Modelfile should be printed
+func (c *CLI) GetModelfile() string {
+	return c.modelfile
+}
+
+// GetRunMode determines the run mode from the parsed flags.
+// It returns an error when flags selecting different modes are combined.
+func (c *CLI) GetRunMode() (RunMode, error) {
+	var modes []string
+	mode := InteractiveMode
+	if c.listProfiles {
+		modes = append(modes, "--list-profiles")
+		mode = ListProfilesMode
+	}
+	if c.initEnv {
+		modes = append(modes, "--init")
+		mode = InitMode
+	}
+	if c.showModelInfo {
+		modes = append(modes, "--model-info")
+		mode = ModelInfoMode
+	}
+	if c.modelfile != "" {
+		modes = append(modes, "--modelfile")
+		mode = ModelfileMode
+	}
+	if c.showModelSize {
+		modes = append(modes, "--model-size")
+		mode = ModelSizeMode
+	}
+	if c.vramUsage {
+		modes = append(modes, "--vram-usage")
+		mode = VRAMUsageMode
+	}
+	if c.pushModel != "" {
+		modes = append(modes, "--push-model")
+		mode = PushModelMode
+	}
+	if len(modes) > 1 {
+		return mode, fmt.Errorf("conflicting flags: %s cannot be combined", strings.Join(modes, ", "))
+	}
+	if len(modes) == 0 && (c.IsStructuredOutput() || !c.interactive) {
+		mode = StdinMode
+	}
+	return mode, nil
+}
+
+// IsInteractive reports whether the conversation reads messages from the terminal
+func (c *CLI) IsInteractive() bool {
+	mode, err := c.GetRunMode()
+	return err == nil && mode == InteractiveMode
+}
+
+// GetShowModelSize returns the model-size flag value
+func (c *CLI) GetShowModelSize() bool {
+	return c.showModelSize
+}
+
+// GetRawCompletion returns the raw-completion flag value
+func (c *CLI) GetRawCompletion() bool {
+	return c.rawCompletion
+}
+
+// GetNoStream returns the no-stream flag value
+func (c *CLI) GetNoStream() bool {
+	return c.noStream
+}
+
+// GetStreamDelay returns the stream-delay flag value
+func (c *CLI) GetStreamDelay() time.Duration {
+	return c.streamDelay
+}
+
+// GetRequestTimeout returns the timeout flag value
+func (c *CLI) GetRequestTimeout() time.Duration {
+	return c.requestTimeout
+}
+
+// GetPushModel returns the model to push to the Ollama registry
+func (c *CLI) GetPushModel() string {
+	return c.pushModel
+}
+
+// GetMaxResponseLines returns the max-response-lines flag value
+func (c *CLI) GetMaxResponseLines() int {
+	return c.maxResponseLines
+}
+
+// SetWriter sets the writer that receives prompts, messages and response output
+func (c *CLI) SetWriter(w io.Writer) {
+	c.writer = w
+}
+
+// GetWriter returns the writer that receives prompts, messages and response output
+func (c *CLI) GetWriter() io.Writer {
+	return c.writer
+}
+
+// SetOutputWriter sets the writer that receives prompts, messages and response output.
+//
+// Deprecated: use SetWriter.
+func (c *CLI) SetOutputWriter(w io.Writer) {
+	c.SetWriter(w)
+}
+
+// GetOutputWriter returns the writer that receives prompts, messages and response output.
+//
+// Deprecated: use GetWriter.
+func (c *CLI) GetOutputWriter() io.Writer {
+	return c.GetWriter()
+}
+
+// SetErrorWriter sets the writer that receives status and error messages
+func (c *CLI) SetErrorWriter(w io.Writer) {
+	c.errWriter = w
+}
+
+// GetErrorWriter returns the writer that receives status and error messages
+func (c *CLI) GetErrorWriter() io.Writer {
+	return c.errWriter
+}
+
+// GetWatchSystemPrompt returns the watch-system-prompt flag value
+func (c *CLI) GetWatchSystemPrompt() bool {
+	return c.watchPrompt
+}
+
+// GetReasoningEffort returns the reasoning-effort flag value
+func (c *CLI) GetReasoningEffort() string {
+	return c.reasoningEffort
+}
+
+// GetQuiet returns the quiet flag value
+func (c *CLI) GetQuiet() bool {
+	return c.quiet
+}
+
+// GetVerbose returns the verbose flag value
+func (c *CLI) GetVerbose() bool {
+	return c.verbose
+}
+
+// GetIncludeUsage returns the include-usage-in-response flag value
+func (c *CLI) GetIncludeUsage() bool {
+	return c.includeUsage
+}
+
+// GetNoTotalUsage returns the no-total-usage flag value
+func (c *CLI) GetNoTotalUsage() bool {
+	return c.noTotalUsage
+}
+
+// GetNumCtx returns the num-ctx flag value
+func (c *CLI) GetNumCtx() int {
+	return c.numCtx
+}
+
+// GetMaxTokens returns the max-tokens flag value
+func (c *CLI) GetMaxTokens() int {
+	return c.maxTokens
+}
+
+// GetStopSequences returns the values of the repeatable stop flag
+func (c *CLI) GetStopSequences() []string {
+	return c.stopSequences
+}
+
+// GetCostWarningThreshold returns the cost-warning-threshold flag value
+func (c *CLI) GetCostWarningThreshold() float64 {
+	return c.costThreshold
+}
+
+// GetAppendSuffix returns the append-suffix flag value
+func (c *CLI) GetAppendSuffix() string {
+	return c.appendSuffix
+}
+
+// GetPrependPrefix returns the prepend-prefix flag value
+func (c *CLI) GetPrependPrefix() string {
+	return c.prependPrefix
+}
+
+// GetResponseSchemaFile returns the response schema file path
+func (c *CLI) GetResponseSchemaFile() string {
+	return c.responseSchema
+}
+
+// GetLogFile returns the audit log file path
+func (c *CLI) GetLogFile() string {
+	return c.logFile
+}
+
+// GetResumeID returns the ID of the conversation to resume
+func (c *CLI) GetResumeID() string {
+	return c.resumeID
+}
+
+// GetParallelToolCalls returns the parallel tool calls setting, or nil when neither
+// --parallel-tool-calls nor --no-parallel-tool-calls is set
+func (c *CLI) GetParallelToolCalls() *bool {
+	switch {
+	case c.parallelTools:
+		enabled := true
+		return &enabled
+	case c.noParallelTools:
+		enabled := false
+		return &enabled
+	}
+	return nil
+}
+
+// GetMessageTemplateFile returns the message template file path
+func (c *CLI) GetMessageTemplateFile() string {
+	return c.messageTemplate
+}
+
+// GetConversationExport returns the format and file of --conversation-export, or empty
+// strings when the conversation is not exported
+func (c *CLI) GetConversationExport() (format, file string) {
+	format, file, err := ParseKeyValue(c.export, "=")
+	if err != nil {
+		return "", ""
+	}
+	return format, file
+}
+
+// GetStreamBuffer returns the stream-buffer flag value
+func (c *CLI) GetStreamBuffer() int {
+	return c.streamBuffer
+}
+
+// GetConversationFile returns the conversation-file flag value
+func (c *CLI) GetConversationFile() string {
+	return c.conversationFile
+}
+
+// GetExportMarkdown returns the export-md flag value
+func (c *CLI) GetExportMarkdown() string {
+	return c.exportMarkdown
+}
+
+// GetForce returns the force flag value
+func (c *CLI) GetForce() bool {
+	return c.force
+}
+
+// GetConversationStats returns the conversation-stats flag value
+func (c *CLI) GetConversationStats() bool {
+	return c.sessionReport
+}
+
+// GetMetricsAddr returns the address of the metrics server, empty when disabled
+func (c *CLI) GetMetricsAddr() string {
+	return c.metricsAddr
+}
+
+// GetUserAgent returns the user-agent flag value
+func (c *CLI) GetUserAgent() string {
+	return c.userAgent
+}
+
+// GetConversationID returns the conversation identifier
+func (c *CLI) GetConversationID() string {
+	return c.conversationID
+}
+
+// GetNoTokenUsage returns the no-token-usage flag value
+func (c *CLI) GetNoTokenUsage() bool {
+	return c.noTokenUsage
+}
+
+// stringList is a flag collecting the values of every occurrence
+type stringList []string
+
+// String returns the values separated by commas
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+// Set appends a value
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
@@ -0,0 +1,26 @@
+package cli
+
+import (
+	"os"
+	"testing"
+)
+
+func TestAutodetectTTYWithPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	stdout := os.Stdout
+	os.Stdout = w
+	t.Cleanup(func() { os.Stdout = stdout })
+
+	c := &CLI{}
+	if c.AutodetectTTY() {
+		t.Error("AutodetectTTY() = true with stdout redirected to a pipe, want false")
+	}
+}
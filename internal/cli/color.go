@@ -0,0 +1,24 @@
+package cli
+
+import "os"
+
+// Color is an ANSI foreground color escape code
+type Color string
+
+// Colors used for conversation output
+const (
+	ColorBlue   Color = "\033[34m"
+	ColorGreen  Color = "\033[32m"
+	ColorYellow Color = "\033[33m"
+)
+
+// colorReset restores the default terminal color
+const colorReset = "\033[0m"
+
+// Colorize wraps text in the ANSI codes of the given color, unless NO_COLOR is set
+func Colorize(text string, color Color) string {
+	if os.Getenv("NO_COLOR") != "" {
+		return text
+	}
+	return string(color) + text + colorReset
+}
@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+// inputEncodings maps the supported --input-encoding names to their character maps
+var inputEncodings = map[string]encoding.Encoding{
+	"cp437":        charmap.CodePage437,
+	"cp850":        charmap.CodePage850,
+	"cp1250":       charmap.Windows1250,
+	"windows-1250": charmap.Windows1250,
+	"cp1251":       charmap.Windows1251,
+	"windows-1251": charmap.Windows1251,
+	"cp1252":       charmap.Windows1252,
+	"windows-1252": charmap.Windows1252,
+	"iso-8859-1":   charmap.ISO8859_1,
+	"latin1":       charmap.ISO8859_1,
+	"iso-8859-2":   charmap.ISO8859_2,
+	"iso-8859-15":  charmap.ISO8859_15,
+	"koi8-r":       charmap.KOI8R,
+}
+
+// applyInputEncoding makes stdin input be decoded from the named encoding into UTF-8.
+// An empty name or "utf-8" keeps the input unchanged.
+func (c *CLI) applyInputEncoding(name string) error {
+	name = strings.ToLower(name)
+	if name == "" || name == "utf-8" || name == "utf8" {
+		return nil
+	}
+	enc, ok := inputEncodings[name]
+	if !ok {
+		return fmt.Errorf("unsupported --input-encoding '%s'", name)
+	}
+	c.reader = bufio.NewReader(transform.NewReader(os.Stdin, enc.NewDecoder()))
+	return nil
+}
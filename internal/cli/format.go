@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"llm-go/internal/format"
+)
+
+// FormatSize formats a size in bytes as a human-readable string using KB/MB/GB units
+func FormatSize(bytes int64) string {
+	const unit = 1024
+	switch {
+	case bytes >= unit*unit*unit:
+		return fmt.Sprintf("%.1f GB", float64(bytes)/(unit*unit*unit))
+	case bytes >= unit*unit:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(unit*unit))
+	case bytes >= unit:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/unit)
+	default:
+		return fmt.Sprintf("%d B", bytes)
+	}
+}
+
+// FormatDuration formats a duration as a human-readable string such as "1m 23s",
+// "23.456s", "456ms" or "<1ms"
+func FormatDuration(d time.Duration) string {
+	return format.Duration(d)
+}
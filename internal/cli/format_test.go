@@ -0,0 +1,33 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		name string
+		d    time.Duration
+		want string
+	}{
+		{"zero", 0, "<1ms"},
+		{"sub-millisecond", 400 * time.Microsecond, "<1ms"},
+		{"milliseconds", 456 * time.Millisecond, "456ms"},
+		{"rounded milliseconds", 456700 * time.Microsecond, "457ms"},
+		{"one second", time.Second, "1s"},
+		{"seconds", 23456 * time.Millisecond, "23.456s"},
+		{"one minute", time.Minute, "1m 0s"},
+		{"minutes", time.Minute + 23500*time.Millisecond, "1m 24s"},
+		{"one hour", time.Hour, "1h 0m 0s"},
+		{"hours", 2*time.Hour + 5*time.Minute + 9*time.Second, "2h 5m 9s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatDuration(tt.d); got != tt.want {
+				t.Errorf("FormatDuration(%v) = %q, want %q", tt.d, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,40 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ParseKeyValue splits s into a key and value at the first occurrence of sep. Both are
+// trimmed; quotes are not interpreted and are kept as part of the key and value.
+func ParseKeyValue(s, sep string) (key, value string, err error) {
+	key, value, found := strings.Cut(s, sep)
+	if !found {
+		return "", "", fmt.Errorf("invalid value '%s': expected format key%svalue", s, sep)
+	}
+
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	if key == "" {
+		return "", "", fmt.Errorf("invalid value '%s': key must not be empty", s)
+	}
+	if value == "" {
+		return "", "", fmt.Errorf("invalid value '%s': value must not be empty", s)
+	}
+
+	return key, value, nil
+}
+
+// ParseKeyValueSlice parses every entry of a repeatable key-value flag into a map.
+// Later entries override earlier ones with the same key.
+func ParseKeyValueSlice(ss []string, sep string) (map[string]string, error) {
+	result := make(map[string]string, len(ss))
+	for _, s := range ss {
+		key, value, err := ParseKeyValue(s, sep)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		s         string
+		sep       string
+		wantKey   string
+		wantValue string
+		wantErr   bool
+	}{
+		{"simple", "Authorization=Bearer token", "=", "Authorization", "Bearer token", false},
+		{"trimmed", "  key = value  ", "=", "key", "value", false},
+		{"separator in value", "query=a=b=c", "=", "query", "a=b=c", false},
+		{"other separator", "user:Hello: world", ":", "user", "Hello: world", false},
+		{"double quotes kept", `name="a b"`, "=", "name", `"a b"`, false},
+		{"single quotes kept", `'key'='value'`, "=", "'key'", "'value'", false},
+		{"quoted separator", `key="a=b"`, "=", "key", `"a=b"`, false},
+		{"empty string", "", "=", "", "", true},
+		{"missing separator", "key", "=", "", "", true},
+		{"empty key", "=value", "=", "", "", true},
+		{"blank key", "   =value", "=", "", "", true},
+		{"empty value", "key=", "=", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, value, err := ParseKeyValue(tt.s, tt.sep)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseKeyValue(%q, %q) error = %v, wantErr %v", tt.s, tt.sep, err, tt.wantErr)
+			}
+			if key != tt.wantKey || value != tt.wantValue {
+				t.Errorf("ParseKeyValue(%q, %q) = %q, %q, want %q, %q", tt.s, tt.sep, key, value, tt.wantKey, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestParseKeyValueSlice(t *testing.T) {
+	got, err := ParseKeyValueSlice([]string{"a=1", "b=2=3", "a=4"}, "=")
+	if err != nil {
+		t.Fatalf("ParseKeyValueSlice() error = %v", err)
+	}
+	want := map[string]string{"a": "4", "b": "2=3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseKeyValueSlice() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseKeyValueSlice([]string{"a=1", "=2"}, "="); err == nil {
+		t.Error("ParseKeyValueSlice() with an empty key succeeded, want an error")
+	}
+}
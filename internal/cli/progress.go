@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/term"
+)
+
+// spinnerFrames are the animation frames shown by ShowProgress
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// ShowProgress prints msg to stderr and animates a spinner until done is closed.
+// When stderr is not a terminal it prints "msg..." once and "done" on completion.
+func ShowProgress(msg string, done <-chan struct{}) {
+	if !term.IsTerminal(int(os.Stderr.Fd())) {
+		fmt.Fprintf(os.Stderr, "%s...", msg)
+		<-done
+		fmt.Fprintln(os.Stderr, "done")
+		return
+	}
+
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+
+	for frame := 0; ; frame++ {
+		fmt.Fprintf(os.Stderr, "\r%s %s", msg, spinnerFrames[frame%len(spinnerFrames)])
+		select {
+		case <-done:
+			fmt.Fprintf(os.Stderr, "\r%s done\n", msg)
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package cli
+
+import "fmt"
+
+// CommandHandler runs a subcommand with the arguments following its name
+type CommandHandler func(args []string) error
+
+// Router dispatches positional subcommands such as "llm-go chat ..." to their handlers
+type Router struct {
+	handlers       map[string]CommandHandler
+	defaultCommand string
+}
+
+// NewRouter creates a router that runs defaultCommand when the first argument is not a
+// registered subcommand
+func NewRouter(defaultCommand string) *Router {
+	return &Router{
+		handlers:       make(map[string]CommandHandler),
+		defaultCommand: defaultCommand,
+	}
+}
+
+// Register adds the handler for the named subcommand
+func (r *Router) Register(name string, handler CommandHandler) {
+	r.handlers[name] = handler
+}
+
+// Dispatch runs the subcommand named by the first argument, or the default command with
+// all arguments when it is not a known subcommand
+func (r *Router) Dispatch(args []string) error {
+	if len(args) > 0 {
+		if handler, ok := r.handlers[args[0]]; ok {
+			return handler(args[1:])
+		}
+	}
+
+	handler, ok := r.handlers[r.defaultCommand]
+	if !ok {
+		return fmt.Errorf("no handler registered for default command '%s'", r.defaultCommand)
+	}
+	return handler(args)
+}
@@ -0,0 +1,14 @@
+package cli
+
+import "io"
+
+// NewMultiWriter returns a writer that duplicates its writes to all non-nil writers
+func NewMultiWriter(writers ...io.Writer) io.Writer {
+	active := make([]io.Writer, 0, len(writers))
+	for _, w := range writers {
+		if w != nil {
+			active = append(active, w)
+		}
+	}
+	return io.MultiWriter(active...)
+}
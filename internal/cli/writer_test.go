@@ -0,0 +1,17 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewMultiWriter(t *testing.T) {
+	var display, log strings.Builder
+	w := NewMultiWriter(&display, nil, &log)
+	fmt.Fprint(w, "Tokens: Input 10")
+
+	if display.String() != "Tokens: Input 10" || log.String() != "Tokens: Input 10" {
+		t.Errorf("writers got %q and %q, want both %q", display.String(), log.String(), "Tokens: Input 10")
+	}
+}
@@ -1,61 +1,186 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"text/template"
 	"time"
 
+	"llm-go/internal/llm"
+
 	"github.com/joho/godotenv"
 )
 
 // Config holds the configuration for the LLM client
 type Config struct {
-	APIKey       string
-	BaseURL      string
-	Model        string
-	Temperature  float64
-	SystemPrompt string
+	Provider        string  `yaml:"-"`
+	APIKey          string  `yaml:"api_key"`
+	BaseURL         string  `yaml:"base_url"`
+	Model           string  `yaml:"model"`
+	Temperature     float64 `yaml:"temperature"`
+	TopP            float64 `yaml:"top_p"`
+	SystemPrompt    string  `yaml:"system_prompt"`
+	ReasoningEffort string  `yaml:"reasoning_effort"`
+	NumCtx          int     `yaml:"num_ctx"`
+	// MaxTokens caps the length of each completion in tokens (0 = API default)
+	MaxTokens int `yaml:"max_tokens"`
+	// StopSequences end the completion when the model generates one of them
+	StopSequences []string `yaml:"stop_sequences"`
+	// Penalties for repeated tokens, from -2.0 to 2.0 (0 = API default)
+	PresencePenalty  float64 `yaml:"presence_penalty"`
+	FrequencyPenalty float64 `yaml:"frequency_penalty"`
+	// Seed requests deterministic sampling (nil = unset)
+	Seed *int64 `yaml:"seed"`
+	// Timeouts are YAML durations such as "10s"
+	ConnectTimeout       time.Duration `yaml:"connect_timeout"`
+	StreamingIdleTimeout time.Duration `yaml:"streaming_idle_timeout"`
+	// RequestTimeout is the deadline of a whole response, including retries and
+	// reconnects (default 120s)
+	RequestTimeout time.Duration `yaml:"request_timeout"`
+	// TotalTimeout is the former name of RequestTimeout, used when request_timeout is not set.
+	//
+	// Deprecated: use RequestTimeout.
+	TotalTimeout time.Duration `yaml:"total_timeout"`
+	// Verbose logs retry attempts of failed requests to stderr
+	Verbose bool `yaml:"verbose"`
+	// DisableStreaming gets each response from a single non-streaming request
+	DisableStreaming bool `yaml:"disable_streaming"`
+	// DisableTotalUsageOnExit suppresses the token summary at the end of a session
+	DisableTotalUsageOnExit bool `yaml:"disable_total_usage_on_exit"`
+	// CostWarningThreshold asks for confirmation before requests estimated above this USD cost (0 = no warning)
+	CostWarningThreshold float64 `yaml:"cost_warning_threshold"`
+	// ConversationFile is loaded at startup when it exists and written on exit (empty = disabled)
+	ConversationFile string `yaml:"conversation_file"`
+	// StreamBufferSize is the number of chunks buffered between the stream and the
+	// terminal (0 = unbuffered)
+	StreamBufferSize int `yaml:"stream_buffer_size"`
+	// ParallelToolCalls allows or forbids several tool calls per response (nil = API default)
+	ParallelToolCalls *bool `yaml:"parallel_tool_calls"`
+	// UserAgent is sent with every HTTP request (empty = llm-go/<version> (<goos>/<goarch>; go<version>))
+	UserAgent string `yaml:"user_agent"`
+	// BaseURLAliases maps shorthand names such as "groq" to base URLs, extending the built-in ones
+	BaseURLAliases map[string]string `yaml:"base_url_aliases,omitempty"`
+	Profiles       map[string]Config `yaml:"profiles,omitempty"`
+
+	// ResponseSchema is the JSON schema the response must follow (nil = free-form text)
+	ResponseSchema json.RawMessage `yaml:"-"`
+	// MessageTemplate wraps each user message, available as {{.Message}} (nil = sent as is)
+	MessageTemplate *template.Template `yaml:"-"`
+	// ThinkStartTag and ThinkEndTag delimit thinking blocks (empty = <think> and </think>)
+	ThinkStartTag string `yaml:"think_start_tag"`
+	ThinkEndTag   string `yaml:"think_end_tag"`
+
+	// ResponseFilter post-processes each response, without thinking blocks, before it is
+	// stored and output as JSON. Streamed text is displayed unfiltered. Set by embedding
+	// applications only.
+	ResponseFilter func(string) string `yaml:"-"`
+}
+
+// Options holds the command-line values passed to LoadConfig
+type Options struct {
+	ConfigFile           string
+	Profile              string
+	SystemPrompt         string
+	Model                string
+	Temperature          float64
+	TopP                 float64
+	PresencePenalty      float64
+	FrequencyPenalty     float64
+	Seed                 *int64
+	ReasoningEffort      string
+	NumCtx               int
+	MaxTokens            int
+	StopSequences        []string
+	CostWarningThreshold float64
+	ResponseSchema       json.RawMessage
+	MessageTemplate      *template.Template
+	StreamBufferSize     int
+	ConversationFile     string
+	RequestTimeout       time.Duration
+	ParallelToolCalls    *bool
+	UserAgent            string
+	// DisableTotalUsageOnExit is set by --no-total-usage
+	DisableTotalUsageOnExit bool
+	// Verbose is set by --verbose
+	Verbose bool
+	// DisableStreaming is set by --no-stream
+	DisableStreaming bool
+	// ThinkStartTag and ThinkEndTag are set by --think-start and --think-end
+	ThinkStartTag string
+	ThinkEndTag   string
 }
 
-// LoadConfig loads configuration with CLI arguments taking precedence over environment variables
-func LoadConfig(systemPrompt, cliModel string, cliTemperature float64) Config {
-	// Load .env file if it exists
-	_ = godotenv.Load()
+// Default timeouts used when the config file does not set them
+const (
+	defaultConnectTimeout       = 10 * time.Second
+	defaultStreamingIdleTimeout = 30 * time.Second
+	defaultRequestTimeout       = 120 * time.Second
+)
 
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		fmt.Println("Warning: OPENAI_API_KEY environment variable is not set")
-	}
+// maxStopSequences is the number of stop sequences accepted by the OpenAI API
+const maxStopSequences = 4
 
-	baseURL := os.Getenv("OPENAI_BASE_URL")
-	if baseURL == "" {
-		baseURL = "https://api.openai.com/v1"
+// LoadConfig loads configuration with the following precedence (highest first):
+// CLI arguments, environment variables, the selected profile, the config file base values,
+// and built-in defaults.
+func LoadConfig(opts Options) (Config, error) {
+	// Load the nearest .env file if one exists
+	if envFile := FindDotEnv(); envFile != "" {
+		_ = godotenv.Load(envFile)
 	}
 
-	// Prioritize CLI model over environment variable
-	model := cliModel
-	if model == "" {
-		model = os.Getenv("OPENAI_MODEL")
-		if model == "" {
-			model = "gpt-4o"
+	base, err := LoadConfigFile(opts.ConfigFile)
+	if err != nil {
+		return Config{}, err
+	}
+	base.APIKey = expandTemplate(base.APIKey)
+	base.BaseURL = expandTemplate(base.BaseURL)
+	base.Model = expandTemplate(base.Model)
+	base.SystemPrompt = expandTemplate(base.SystemPrompt)
+	if opts.Profile != "" {
+		base, err = applyProfile(base, opts.Profile)
+		if err != nil {
+			return Config{}, err
 		}
 	}
 
+	conn := resolveConnection(base, opts.Model)
+	if conn.apiKey == "" {
+		fmt.Fprintln(os.Stderr, "Warning: neither OPENAI_API_KEY nor GOOGLE_API_KEY environment variable is set")
+	}
+	aliases := baseURLAliases(base.BaseURLAliases)
+	conn.baseURL = expandBaseURLAlias(conn.baseURL, aliases)
+	conn.baseURL, err = normalizeBaseURL(conn.baseURL)
+	if err != nil {
+		return Config{}, err
+	}
+
 	// Use the provided system prompt instead of environment variable
+	systemPrompt := opts.SystemPrompt
 	if systemPrompt == "" {
-		systemPrompt = "You are a helpful assistant."
+		systemPrompt = base.SystemPrompt
+		if systemPrompt == "" {
+			systemPrompt = "You are a helpful assistant."
+		}
 	}
 
 	// Prioritize CLI temperature over environment variable
 	temperature := 0.7 // default temperature
-	if cliTemperature != 0.0 {
+	if base.Temperature != 0.0 {
+		temperature = base.Temperature
+	}
+	if opts.Temperature != 0.0 {
 		// Validate temperature range (0.0 to 2.0)
-		if cliTemperature >= 0.0 && cliTemperature <= 2.0 {
-			temperature = cliTemperature
+		if opts.Temperature >= 0.0 && opts.Temperature <= 2.0 {
+			temperature = opts.Temperature
 		} else {
-			fmt.Printf("Warning: Temperature value %f is outside valid range (0.0-2.0), using default 0.7\n", cliTemperature)
+			fmt.Fprintf(os.Stderr, "Warning: Temperature value %f is outside valid range (0.0-2.0), using %.1f\n", opts.Temperature, temperature)
 		}
 	} else {
 		// Fall back to environment variable
@@ -66,25 +191,242 @@ func LoadConfig(systemPrompt, cliModel string, cliTemperature float64) Config {
 				if parsedTemp >= 0.0 && parsedTemp <= 2.0 {
 					temperature = parsedTemp
 				} else {
-					fmt.Printf("Warning: Temperature value %f is outside valid range (0.0-2.0), using default 0.7\n", parsedTemp)
+					fmt.Fprintf(os.Stderr, "Warning: Temperature value %f is outside valid range (0.0-2.0), using %.1f\n", parsedTemp, temperature)
 				}
 			} else {
-				fmt.Printf("Warning: Invalid temperature value '%s', using default 0.7\n", temperatureStr)
+				fmt.Fprintf(os.Stderr, "Warning: Invalid temperature value '%s', using %.1f\n", temperatureStr, temperature)
 			}
 		}
 	}
 
+	// Sampling parameters are left unset by default
+	topP := optionalFloat("Top-p", opts.TopP, "OPENAI_TOP_P", base.TopP, 0.0, 1.0)
+	presencePenalty := optionalFloat("Presence penalty", opts.PresencePenalty, "OPENAI_PRESENCE_PENALTY", base.PresencePenalty, -2.0, 2.0)
+	frequencyPenalty := optionalFloat("Frequency penalty", opts.FrequencyPenalty, "OPENAI_FREQUENCY_PENALTY", base.FrequencyPenalty, -2.0, 2.0)
+
+	// Prioritize CLI seed over environment variable
+	seed := opts.Seed
+	if seed == nil {
+		if seedStr := os.Getenv("OPENAI_SEED"); seedStr != "" {
+			if parsedSeed, err := strconv.ParseInt(seedStr, 10, 64); err == nil {
+				seed = &parsedSeed
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: Invalid seed value '%s', ignoring it\n", seedStr)
+			}
+		}
+	}
+	if seed == nil {
+		seed = base.Seed
+	}
+
+	reasoningEffort := opts.ReasoningEffort
+	if reasoningEffort == "" {
+		reasoningEffort = base.ReasoningEffort
+	}
+	if reasoningEffort != "" && !llm.IsReasoningModel(conn.model) {
+		fmt.Fprintf(os.Stderr, "Warning: reasoning effort is only supported by o1, o3 and o4 models, '%s' may ignore it\n", conn.model)
+	}
+
+	numCtx := opts.NumCtx
+	if numCtx == 0 {
+		numCtx = base.NumCtx
+	}
+
+	maxTokens := opts.MaxTokens
+	if maxTokens == 0 {
+		maxTokens = base.MaxTokens
+	}
+	if maxTokens < 0 {
+		return Config{}, fmt.Errorf("invalid max tokens %d: must be positive", maxTokens)
+	}
+
+	// Prioritize CLI stop sequences over the comma-separated environment variable
+	stopSequences := opts.StopSequences
+	if len(stopSequences) == 0 {
+		if stopStr := os.Getenv("OPENAI_STOP"); stopStr != "" {
+			stopSequences = splitStopSequences(stopStr)
+		} else {
+			stopSequences = base.StopSequences
+		}
+	}
+	// Empty stop sequences are rejected by the API
+	stopSequences = slices.DeleteFunc(slices.Clone(stopSequences), func(stop string) bool { return stop == "" })
+	if len(stopSequences) > maxStopSequences {
+		return Config{}, fmt.Errorf("too many stop sequences: %d, at most %d are supported", len(stopSequences), maxStopSequences)
+	}
+
+	costWarningThreshold := opts.CostWarningThreshold
+	if costWarningThreshold == 0 {
+		costWarningThreshold = base.CostWarningThreshold
+	}
+
+	streamBufferSize := opts.StreamBufferSize
+	if streamBufferSize == 0 {
+		streamBufferSize = base.StreamBufferSize
+	}
+	if streamBufferSize < 0 {
+		fmt.Fprintf(os.Stderr, "Warning: stream buffer size %d is negative, using an unbuffered stream\n", streamBufferSize)
+		streamBufferSize = 0
+	}
+
+	conversationFile := opts.ConversationFile
+	if conversationFile == "" {
+		conversationFile = base.ConversationFile
+	}
+
+	parallelToolCalls := opts.ParallelToolCalls
+	if parallelToolCalls == nil {
+		parallelToolCalls = base.ParallelToolCalls
+	}
+
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = base.UserAgent
+	}
+
+	thinkStartTag := opts.ThinkStartTag
+	if thinkStartTag == "" {
+		thinkStartTag = base.ThinkStartTag
+	}
+	thinkEndTag := opts.ThinkEndTag
+	if thinkEndTag == "" {
+		thinkEndTag = base.ThinkEndTag
+	}
+
+	connectTimeout := base.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
+	streamingIdleTimeout := base.StreamingIdleTimeout
+	if streamingIdleTimeout == 0 {
+		streamingIdleTimeout = defaultStreamingIdleTimeout
+	}
+
+	// Prioritize CLI request timeout over environment variable, and the current config
+	// file name over the deprecated one
+	requestTimeout := base.RequestTimeout
+	if requestTimeout == 0 {
+		requestTimeout = base.TotalTimeout
+	}
+	if opts.RequestTimeout > 0 {
+		requestTimeout = opts.RequestTimeout
+	} else if timeoutStr := os.Getenv("LLM_REQUEST_TIMEOUT"); timeoutStr != "" {
+		if parsedTimeout, err := time.ParseDuration(timeoutStr); err == nil && parsedTimeout > 0 {
+			requestTimeout = parsedTimeout
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: Invalid request timeout '%s', ignoring it\n", timeoutStr)
+		}
+	}
+	if requestTimeout <= 0 {
+		requestTimeout = defaultRequestTimeout
+	}
+
 	return Config{
-		APIKey:       apiKey,
-		BaseURL:      baseURL,
-		Model:        model,
-		Temperature:  temperature,
-		SystemPrompt: systemPrompt,
+		Provider:                conn.provider,
+		APIKey:                  conn.apiKey,
+		BaseURL:                 conn.baseURL,
+		Model:                   conn.model,
+		Temperature:             temperature,
+		TopP:                    topP,
+		PresencePenalty:         presencePenalty,
+		FrequencyPenalty:        frequencyPenalty,
+		Seed:                    seed,
+		SystemPrompt:            systemPrompt,
+		ReasoningEffort:         reasoningEffort,
+		NumCtx:                  numCtx,
+		MaxTokens:               maxTokens,
+		StopSequences:           stopSequences,
+		ConnectTimeout:          connectTimeout,
+		StreamingIdleTimeout:    streamingIdleTimeout,
+		RequestTimeout:          requestTimeout,
+		DisableTotalUsageOnExit: opts.DisableTotalUsageOnExit || base.DisableTotalUsageOnExit,
+		Verbose:                 opts.Verbose || base.Verbose,
+		DisableStreaming:        opts.DisableStreaming || base.DisableStreaming,
+		CostWarningThreshold:    costWarningThreshold,
+		ResponseSchema:          opts.ResponseSchema,
+		MessageTemplate:         opts.MessageTemplate,
+		ThinkStartTag:           thinkStartTag,
+		ThinkEndTag:             thinkEndTag,
+		StreamBufferSize:        streamBufferSize,
+		ConversationFile:        conversationFile,
+		ParallelToolCalls:       parallelToolCalls,
+		UserAgent:               userAgent,
+		BaseURLAliases:          aliases,
+		Profiles:                base.Profiles,
+	}, nil
+}
+
+// optionalFloat returns the CLI value of an optional parameter, falling back to the
+// environment variable and then to the config file value. Values outside [minValue,
+// maxValue] are ignored with a warning.
+func optionalFloat(name string, cliValue float64, envVar string, fileValue, minValue, maxValue float64) float64 {
+	value := fileValue
+	if cliValue != 0.0 {
+		if cliValue >= minValue && cliValue <= maxValue {
+			value = cliValue
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: %s value %f is outside valid range (%.1f-%.1f), ignoring it\n", name, cliValue, minValue, maxValue)
+		}
+	} else if str := os.Getenv(envVar); str != "" {
+		if parsed, err := strconv.ParseFloat(str, 64); err == nil {
+			if parsed >= minValue && parsed <= maxValue {
+				value = parsed
+			} else {
+				fmt.Fprintf(os.Stderr, "Warning: %s value %f is outside valid range (%.1f-%.1f), ignoring it\n", name, parsed, minValue, maxValue)
+			}
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: Invalid %s value '%s', ignoring it\n", strings.ToLower(name), str)
+		}
+	}
+	return value
+}
+
+// FindDotEnv returns the path of the nearest .env file, searching from the current
+// directory up to $HOME or the filesystem root, whichever comes first
+func FindDotEnv() string {
+	dir, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	home, _ := os.UserHomeDir()
+
+	for {
+		candidate := filepath.Join(dir, ".env")
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate
+		}
+		parent := filepath.Dir(dir)
+		if dir == home || parent == dir {
+			return ""
+		}
+		dir = parent
 	}
 }
 
-// formatCurrentDateTime returns current datetime in "Tuesday 1 September 2025, 10:17 AM" format
-func formatCurrentDateTime() string {
+// splitStopSequences splits the comma-separated OPENAI_STOP value, trimming spaces
+// around each entry so "END, ###" works as expected
+func splitStopSequences(s string) []string {
+	var stops []string
+	for _, stop := range strings.Split(s, ",") {
+		if stop = strings.TrimSpace(stop); stop != "" {
+			stops = append(stops, stop)
+		}
+	}
+	return stops
+}
+
+// normalizeBaseURL validates that rawURL is an http or https URL with a host and strips
+// trailing slashes to prevent double slashes when paths are appended
+func normalizeBaseURL(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", fmt.Errorf("invalid OPENAI_BASE_URL: must be an http or https URL, got: %s", rawURL)
+	}
+	return strings.TrimRight(rawURL, "/"), nil
+}
+
+// FormatCurrentDateTime returns current datetime in "Tuesday 1 September 2025, 10:17 AM" format
+func FormatCurrentDateTime() string {
 	now := time.Now()
 	// Format day with ordinal suffix
 	day := now.Day()
@@ -104,6 +446,99 @@ func ReadSystemPrompt(filePath string) (string, error) {
 	if err != nil {
 		return "", fmt.Errorf("failed to read system prompt file: %w", err)
 	}
-	prompt := strings.TrimSpace(string(content))
-	return strings.ReplaceAll(prompt, "{{currentDateTime}}", formatCurrentDateTime()), nil
+	return expandTemplate(strings.TrimSpace(string(content))), nil
+}
+
+// ReadResponseSchema reads a JSON schema file used for structured outputs
+func ReadResponseSchema(filePath string) (json.RawMessage, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response schema file: %w", err)
+	}
+	var schema map[string]any
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return nil, fmt.Errorf("invalid response schema %s: must be a JSON object: %w", filePath, err)
+	}
+	return json.RawMessage(content), nil
+}
+
+// ReadMessageTemplate reads a text/template file used to wrap each user message
+func ReadMessageTemplate(filePath string) (*template.Template, error) {
+	content, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read message template file: %w", err)
+	}
+	tmpl, err := template.New(filepath.Base(filePath)).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid message template %s: %w", filePath, err)
+	}
+	return tmpl, nil
+}
+
+// expandTemplate replaces the supported {{...}} placeholders in s
+func expandTemplate(s string) string {
+	return strings.ReplaceAll(s, "{{currentDateTime}}", FormatCurrentDateTime())
+}
+
+// SaveAPIKey appends OPENAI_API_KEY to the .env file at path, creating it if needed
+func SaveAPIKey(path, apiKey string) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "OPENAI_API_KEY=%s\n", apiKey); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// defaultEnvFile is the commented .env template written by GenerateDefaultEnvFile
+const defaultEnvFile = `# llm-go configuration
+# Uncomment and edit the variables you need. CLI flags override these values.
+
+# API key for the OpenAI-compatible API (string)
+OPENAI_API_KEY=
+
+# Base URL of the OpenAI-compatible API (URL or provider alias such as groq or ollama)
+# OPENAI_BASE_URL=https://api.openai.com/v1
+# OPENAI_BASE_URL=http://localhost:11434/v1
+
+# Model to use for completions (string, default: gpt-4o)
+# OPENAI_MODEL=gpt-4o
+
+# Temperature for completions (float, 0.0-2.0, default: 0.7)
+# OPENAI_TEMPERATURE=0.7
+
+# Top-p (nucleus) sampling for completions (float, 0.0-1.0, default: API default)
+# OPENAI_TOP_P=0.9
+
+# Penalties for tokens already present / frequent in the text (float, -2.0-2.0, default: API default)
+# OPENAI_PRESENCE_PENALTY=0.0
+# OPENAI_FREQUENCY_PENALTY=0.0
+
+# Seed for reproducible completions (integer, default: unset)
+# OPENAI_SEED=42
+
+# Comma-separated sequences that end the completion (at most 4)
+# OPENAI_STOP=END,###
+
+# Deadline of each request (duration, default: 120s)
+# LLM_REQUEST_TIMEOUT=120s
+
+# API key for Google Gemini, used when OPENAI_API_KEY is not set (string)
+# GOOGLE_API_KEY=
+
+# Gemini model to use (string, default: gemini-2.0-flash)
+# GOOGLE_MODEL=gemini-2.0-flash
+`
+
+// GenerateDefaultEnvFile writes a commented .env template listing all supported
+// environment variables to path, replacing any existing file
+func GenerateDefaultEnvFile(path string) error {
+	if err := os.WriteFile(path, []byte(defaultEnvFile), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
 }
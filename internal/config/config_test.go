@@ -0,0 +1,127 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestFormatCurrentDateTime(t *testing.T) {
+	pattern := regexp.MustCompile(`^(Monday|Tuesday|Wednesday|Thursday|Friday|Saturday|Sunday) ` +
+		`([1-9]|[12][0-9]|3[01]) ` +
+		`(January|February|March|April|May|June|July|August|September|October|November|December) ` +
+		`[0-9]{4}, ([1-9]|1[0-2]):[0-5][0-9] (AM|PM)$`)
+
+	got := FormatCurrentDateTime()
+	if !pattern.MatchString(got) {
+		t.Errorf("FormatCurrentDateTime() = %q, want a date like \"Tuesday 1 September 2025, 10:17 AM\"", got)
+	}
+}
+
+// isolateEnvironment runs the test in an empty directory with an empty home, so that no
+// .env or config file of the machine is loaded, and clears the configuration variables
+func isolateEnvironment(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	for _, name := range []string{
+		"OPENAI_BASE_URL", "OPENAI_MODEL", "OPENAI_TEMPERATURE", "OPENAI_TOP_P",
+		"OPENAI_PRESENCE_PENALTY", "OPENAI_FREQUENCY_PENALTY", "OPENAI_SEED", "OPENAI_STOP",
+		"LLM_REQUEST_TIMEOUT", "GOOGLE_API_KEY", "GOOGLE_MODEL",
+	} {
+		t.Setenv(name, "")
+	}
+	t.Setenv("OPENAI_API_KEY", "test-key")
+}
+
+func TestLoadConfigDefaults(t *testing.T) {
+	isolateEnvironment(t)
+
+	// No .env exists in the isolated directory
+	if envFile := FindDotEnv(); envFile != "" {
+		t.Fatalf("FindDotEnv() = %q, want no .env file", envFile)
+	}
+
+	cfg, err := LoadConfig(Options{})
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Model != "gpt-4o" {
+		t.Errorf("Model = %q, want %q", cfg.Model, "gpt-4o")
+	}
+	if cfg.BaseURL != "https://api.openai.com/v1" {
+		t.Errorf("BaseURL = %q, want %q", cfg.BaseURL, "https://api.openai.com/v1")
+	}
+	if cfg.Provider != ProviderOpenAI {
+		t.Errorf("Provider = %q, want %q", cfg.Provider, ProviderOpenAI)
+	}
+	if cfg.APIKey != "test-key" {
+		t.Errorf("APIKey = %q, want %q", cfg.APIKey, "test-key")
+	}
+}
+
+func TestLoadConfigThinkTags(t *testing.T) {
+	isolateEnvironment(t)
+	configFile := filepath.Join(t.TempDir(), "config.yaml")
+	content := "think_start_tag: <reasoning>\nthink_end_tag: </reasoning>\n" +
+		"profiles:\n  mistral:\n    think_start_tag: \"[THINK]\"\n    think_end_tag: \"[/THINK]\"\n"
+	if err := os.WriteFile(configFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		opts      Options
+		wantStart string
+		wantEnd   string
+	}{
+		{"config file", Options{}, "<reasoning>", "</reasoning>"},
+		{"profile", Options{Profile: "mistral"}, "[THINK]", "[/THINK]"},
+		{"flags", Options{Profile: "mistral", ThinkStartTag: "<t>", ThinkEndTag: "</t>"}, "<t>", "</t>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.ConfigFile = configFile
+			cfg, err := LoadConfig(tt.opts)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			if cfg.ThinkStartTag != tt.wantStart || cfg.ThinkEndTag != tt.wantEnd {
+				t.Errorf("tags = %q, %q, want %q, %q", cfg.ThinkStartTag, cfg.ThinkEndTag, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestLoadConfigStopSequences(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     string
+		opts    []string
+		want    []string
+		wantErr bool
+	}{
+		{"env trimmed", "END, ###", nil, []string{"END", "###"}, false},
+		{"env empty entries dropped", "a,,b, ,c,d,", nil, []string{"a", "b", "c", "d"}, false},
+		{"env too many", "a,b,c,d,e", nil, nil, true},
+		{"flags empty entries dropped", "", []string{"a", "", "b", "c", "d"}, []string{"a", "b", "c", "d"}, false},
+		{"flags whitespace kept", "", []string{"\n\n"}, []string{"\n\n"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolateEnvironment(t)
+			t.Setenv("OPENAI_STOP", tt.env)
+			cfg, err := LoadConfig(Options{StopSequences: tt.opts})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(cfg.StopSequences, tt.want) {
+				t.Errorf("StopSequences = %q, want %q", cfg.StopSequences, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile returns the path of the config file used when --config is not set
+func DefaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "llm-go", "config.yaml")
+}
+
+// LoadConfigFile reads a YAML config file. An empty path falls back to DefaultConfigFile,
+// and a missing default file yields an empty configuration rather than an error.
+func LoadConfigFile(path string) (Config, error) {
+	explicit := path != ""
+	if !explicit {
+		path = DefaultConfigFile()
+		if path == "" {
+			return Config{}, nil
+		}
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if !explicit && errors.Is(err, os.ErrNotExist) {
+			return Config{}, nil
+		}
+		return Config{}, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(content, &cfg); err != nil {
+		return Config{}, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// ProfileNames returns the sorted names of the profiles defined in the config
+func (c Config) ProfileNames() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// applyProfile merges the named profile over the base config.
+// Only fields set in the profile override the base values.
+func applyProfile(base Config, name string) (Config, error) {
+	profile, ok := base.Profiles[name]
+	if !ok {
+		return base, fmt.Errorf("profile '%s' not found in config file", name)
+	}
+
+	if profile.APIKey != "" {
+		base.APIKey = expandTemplate(profile.APIKey)
+	}
+	if profile.BaseURL != "" {
+		base.BaseURL = expandTemplate(profile.BaseURL)
+	}
+	if profile.Model != "" {
+		base.Model = expandTemplate(profile.Model)
+	}
+	if profile.Temperature != 0.0 {
+		base.Temperature = profile.Temperature
+	}
+	if profile.TopP != 0.0 {
+		base.TopP = profile.TopP
+	}
+	if profile.PresencePenalty != 0.0 {
+		base.PresencePenalty = profile.PresencePenalty
+	}
+	if profile.FrequencyPenalty != 0.0 {
+		base.FrequencyPenalty = profile.FrequencyPenalty
+	}
+	if profile.Seed != nil {
+		base.Seed = profile.Seed
+	}
+	if profile.ReasoningEffort != "" {
+		base.ReasoningEffort = profile.ReasoningEffort
+	}
+	if profile.ConversationFile != "" {
+		base.ConversationFile = profile.ConversationFile
+	}
+	if profile.StreamBufferSize != 0 {
+		base.StreamBufferSize = profile.StreamBufferSize
+	}
+	if profile.NumCtx != 0 {
+		base.NumCtx = profile.NumCtx
+	}
+	if len(profile.StopSequences) > 0 {
+		base.StopSequences = profile.StopSequences
+	}
+	if profile.MaxTokens != 0 {
+		base.MaxTokens = profile.MaxTokens
+	}
+	if profile.SystemPrompt != "" {
+		base.SystemPrompt = expandTemplate(profile.SystemPrompt)
+	}
+	if profile.ConnectTimeout != 0 {
+		base.ConnectTimeout = profile.ConnectTimeout
+	}
+	if profile.StreamingIdleTimeout != 0 {
+		base.StreamingIdleTimeout = profile.StreamingIdleTimeout
+	}
+	if profile.TotalTimeout != 0 {
+		base.TotalTimeout = profile.TotalTimeout
+	}
+	if profile.RequestTimeout != 0 {
+		base.RequestTimeout = profile.RequestTimeout
+	}
+	if profile.CostWarningThreshold != 0 {
+		base.CostWarningThreshold = profile.CostWarningThreshold
+	}
+	if profile.UserAgent != "" {
+		base.UserAgent = profile.UserAgent
+	}
+	if profile.ThinkStartTag != "" {
+		base.ThinkStartTag = profile.ThinkStartTag
+	}
+	if profile.ThinkEndTag != "" {
+		base.ThinkEndTag = profile.ThinkEndTag
+	}
+	if profile.ParallelToolCalls != nil {
+		base.ParallelToolCalls = profile.ParallelToolCalls
+	}
+	if profile.DisableTotalUsageOnExit {
+		base.DisableTotalUsageOnExit = true
+	}
+	if profile.Verbose {
+		base.Verbose = true
+	}
+	if profile.DisableStreaming {
+		base.DisableStreaming = true
+	}
+	return base, nil
+}
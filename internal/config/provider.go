@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Supported provider types
+const (
+	ProviderOpenAI = "openai"
+	ProviderGemini = "gemini"
+)
+
+const (
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+	defaultOpenAIModel   = "gpt-4o"
+
+	// Gemini is reached through Google's OpenAI-compatible endpoint
+	defaultGeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta/openai/"
+	defaultGeminiModel   = "gemini-2.0-flash"
+)
+
+// connection holds the provider-specific settings resolved by LoadConfig
+type connection struct {
+	provider string
+	apiKey   string
+	baseURL  string
+	model    string
+}
+
+// resolveConnection selects the provider from the available API keys and resolves the
+// API key, base URL and model with CLI > environment > config file > default precedence.
+// OpenAI settings win when both an OpenAI and a Google key are available.
+func resolveConnection(base Config, cliModel string) connection {
+	conn := connection{provider: ProviderOpenAI}
+
+	conn.apiKey = os.Getenv("OPENAI_API_KEY")
+	if conn.apiKey == "" {
+		conn.apiKey = base.APIKey
+	}
+	if conn.apiKey == "" {
+		if googleKey := os.Getenv("GOOGLE_API_KEY"); googleKey != "" {
+			return resolveGeminiConnection(googleKey, cliModel)
+		}
+	}
+
+	conn.baseURL = os.Getenv("OPENAI_BASE_URL")
+	if conn.baseURL == "" {
+		conn.baseURL = base.BaseURL
+		if conn.baseURL == "" {
+			conn.baseURL = defaultOpenAIBaseURL
+		}
+	}
+
+	// Prioritize CLI model over environment variable
+	conn.model = cliModel
+	if conn.model == "" {
+		conn.model = os.Getenv("OPENAI_MODEL")
+		if conn.model == "" {
+			conn.model = base.Model
+			if conn.model == "" {
+				conn.model = defaultOpenAIModel
+			}
+		}
+	}
+	return conn
+}
+
+// resolveGeminiConnection builds the settings for Google Gemini
+func resolveGeminiConnection(apiKey, cliModel string) connection {
+	conn := connection{
+		provider: ProviderGemini,
+		apiKey:   apiKey,
+		baseURL:  defaultGeminiBaseURL,
+		model:    cliModel,
+	}
+	if conn.model == "" {
+		conn.model = os.Getenv("GOOGLE_MODEL")
+		if conn.model == "" {
+			conn.model = defaultGeminiModel
+		}
+	}
+	return conn
+}
+
+// defaultBaseURLAliases maps shorthand provider names accepted as base URL to their endpoints
+var defaultBaseURLAliases = map[string]string{
+	"openai":     defaultOpenAIBaseURL,
+	"groq":       "https://api.groq.com/openai/v1",
+	"together":   "https://api.together.xyz/v1",
+	"mistral":    "https://api.mistral.ai/v1",
+	"perplexity": "https://api.perplexity.ai",
+	"openrouter": "https://openrouter.ai/api/v1",
+	"ollama":     "http://localhost:11434/v1",
+}
+
+// baseURLAliases returns the built-in aliases merged with the ones from the config file
+func baseURLAliases(custom map[string]string) map[string]string {
+	aliases := make(map[string]string, len(defaultBaseURLAliases)+len(custom))
+	for name, url := range defaultBaseURLAliases {
+		aliases[name] = url
+	}
+	for name, url := range custom {
+		aliases[strings.ToLower(name)] = url
+	}
+	return aliases
+}
+
+// expandBaseURLAlias replaces a provider alias used as base URL with its endpoint
+func expandBaseURLAlias(baseURL string, aliases map[string]string) string {
+	name := strings.ToLower(strings.TrimSpace(baseURL))
+	expanded, ok := aliases[name]
+	if !ok {
+		return baseURL
+	}
+	fmt.Fprintf(os.Stderr, "Using provider alias: %s → %s\n", name, expanded)
+	return expanded
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// WatchSystemPrompt watches the system prompt file and sends its new content on the
+// returned channel whenever it changes. The watcher stops when stop is closed.
+func WatchSystemPrompt(path string, stop <-chan struct{}) (<-chan string, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch system prompt file: %w", err)
+	}
+
+	// Watch the directory so editors that replace the file on save are also detected
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch system prompt file: %w", err)
+	}
+	if err := watcher.Add(filepath.Dir(absPath)); err != nil {
+		watcher.Close()
+		return nil, fmt.Errorf("failed to watch system prompt file: %w", err)
+	}
+
+	updates := make(chan string, 1)
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != absPath || !event.Has(fsnotify.Write|fsnotify.Create) {
+					continue
+				}
+				prompt, err := ReadSystemPrompt(absPath)
+				if err != nil || prompt == "" {
+					// Editors may truncate the file before writing; wait for the next event
+					continue
+				}
+				// Keep only the latest content if the previous update was not consumed yet
+				select {
+				case <-updates:
+				default:
+				}
+				updates <- prompt
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return updates, nil
+}
@@ -0,0 +1,29 @@
+package format
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Duration formats a duration as a human-readable string such as "1m 23s", "23.456s",
+// "456ms" or "<1ms"
+func Duration(d time.Duration) string {
+	if d < time.Minute {
+		d = d.Round(time.Millisecond)
+	}
+	switch {
+	case d >= time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dh %dm %ds", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	case d >= time.Minute:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm %ds", int(d.Minutes()), int(d.Seconds())%60)
+	case d >= time.Second:
+		return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return "<1ms"
+	}
+}
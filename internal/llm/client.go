@@ -3,25 +3,83 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"regexp"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"llm-go/internal/format"
+
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
 	"github.com/openai/openai-go/packages/param"
+	"github.com/openai/openai-go/shared"
 )
 
+// Default delimiters of thinking blocks
 const (
-	startThinkTag = "<think>"
-	endThinkTag   = "</think>"
+	DefaultThinkStartTag = "<think>"
+	DefaultThinkEndTag   = "</think>"
+)
+
+// ewmaAlpha is the weight of the latest response in the session averages
+const ewmaAlpha = 0.3
+
+// defaultMaxReconnects is used when ReconnectOnDrop is set without MaxReconnects
+const defaultMaxReconnects = 3
+
+// resumeInstruction is sent after a dropped connection to continue the partial response
+const resumeInstruction = "Your previous response was cut off. Continue exactly where it stopped, without repeating anything."
+
+// maxSchemaNameLength is the maximum length of a structured output schema name
+const maxSchemaNameLength = 64
+
+// invalidSchemaNameChars matches the characters not allowed in a schema name
+var invalidSchemaNameChars = regexp.MustCompile(`[^a-zA-Z0-9_-]`)
+
+// sdkStreamErrorPrefix is the prefix of errors returned by the SDK for error events
+const sdkStreamErrorPrefix = "received error while streaming: "
+
+// ErrInStreamError is returned when the backend reports an error inside the stream
+// instead of as an HTTP error
+var ErrInStreamError = errors.New("error reported in stream")
+
+// ErrStreamingIdleTimeout is returned when no chunk is received within StreamingIdleTimeout
+var ErrStreamingIdleTimeout = errors.New("streaming idle timeout")
+
+// Roles used for the initial instruction message
+const (
+	SystemRoleSystem    = "system"
+	SystemRoleDeveloper = "developer"
+)
+
+// ClientInterface is the part of Client used to run a conversation, implemented by
+// Client and MockClient
+type ClientInterface interface {
+	StreamResponse(messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string) (string, error)
+	DisplayTokenUsage(w io.Writer)
+	DisplayTotalUsage(w io.Writer)
+	GetStats() Stats
+}
+
+var (
+	_ ClientInterface = (*Client)(nil)
+	_ ClientInterface = (*MockClient)(nil)
 )
 
 // Client wraps the OpenAI client with additional functionality
 type Client struct {
-	client *openai.Client
-	config Config
+	client    *openai.Client
+	config    Config
+	transport http.RoundTripper
+	// ollamaBaseURL is BaseURL without the OpenAI-compatible /v1 suffix
+	ollamaBaseURL string
 
 	// Token tracking
 	totalInputTokens    int
@@ -29,6 +87,16 @@ type Client struct {
 	currentInputTokens  int
 	currentOutputTokens int
 
+	// API call tracking
+	totalCalls   int
+	currentCalls int
+
+	// Usage reported by individual chunks of the current response
+	usageTimeline []UsageSample
+
+	// Full text of the last successful response, including thinking
+	lastResponse string
+
 	// Time tracking
 	startTime        time.Time
 	endTime          time.Time
@@ -36,6 +104,22 @@ type Client struct {
 	thinkingDuration time.Duration
 	responseStart    time.Time
 	responseDuration time.Duration
+	// firstTokenTime is when the first content of the current response arrived
+	firstTokenTime time.Time
+
+	// systemFingerprint identifies the backend configuration of the current response
+	systemFingerprint string
+
+	totalThinkingDuration time.Duration
+	totalResponseDuration time.Duration
+
+	// Exponentially weighted moving averages of the output throughput and length
+	tokensPerSecondEWMA float64
+	outputTokensEWMA    float64
+
+	// Token usage of each interaction since sessionStart, for GetSessionReport
+	turns        []turnUsage
+	sessionStart time.Time
 
 	mutex sync.Mutex
 }
@@ -46,35 +130,199 @@ type Config struct {
 	BaseURL      string
 	Model        string
 	Temperature  float64
+	TopP         float64
 	SystemPrompt string
+	// SystemPromptRole is "system" or "developer" (default: "developer" for o1/o3/o4 models)
+	SystemPromptRole string
+
+	// ThinkStartTag and ThinkEndTag delimit thinking blocks (default: <think> and </think>)
+	ThinkStartTag string
+	ThinkEndTag   string
+
+	// ReasoningEffort is passed to reasoning models: "low", "medium" or "high" (empty = unset)
+	ReasoningEffort string
+
+	// ReconnectOnDrop retries a stream that drops mid-way, asking the model to continue
+	ReconnectOnDrop bool
+	// MaxReconnects limits reconnect attempts per response (default 3)
+	MaxReconnects int
+
+	// UseStreaming tells callers to use StreamResponse rather than GetResponse
+	UseStreaming bool
+
+	// Retry controls the retries of rate limited (429) and server error (5xx) responses
+	Retry RetryConfig
+	// Verbose logs retry attempts to stderr
+	Verbose bool
+
+	// EmbeddingModel is used by BatchEmbeddings (default: Model)
+	EmbeddingModel string
+
+	// ResponseSchema enforces structured JSON output following this JSON schema (nil = disabled).
+	// Requires a model supporting structured outputs, such as gpt-4o.
+	ResponseSchema json.RawMessage
+
+	// NumCtx overrides the context window of Ollama models (0 = model default)
+	NumCtx int
+
+	// MaxTokens caps the length of each completion in tokens (0 = API default)
+	MaxTokens int
+	// StopSequences end the completion when the model generates one of them (nil = none)
+	StopSequences []string
+	// Penalties for repeated tokens, from -2.0 to 2.0 (0 = API default)
+	PresencePenalty  float64
+	FrequencyPenalty float64
+	// Seed requests deterministic sampling from backends supporting it (nil = unset)
+	Seed *int64
+
+	// MaxToolRounds limits the tool call rounds of ChatWithTools (default 10). The model
+	// gets one more request to answer with the last results; tool calls it still asks for
+	// are not run.
+	MaxToolRounds int
+	// ParallelToolCalls allows or forbids several tool calls per response (nil = API default)
+	ParallelToolCalls *bool
+
+	// FakeStreamDelay is slept after each received chunk to simulate a slow network (0 = disabled)
+	FakeStreamDelay time.Duration
+
+	// ConnectTimeout limits connection establishment (0 = no limit)
+	ConnectTimeout time.Duration
+	// StreamingIdleTimeout limits the time between two stream chunks (0 = no limit)
+	StreamingIdleTimeout time.Duration
+	// RequestTimeout is the deadline of each StreamResponse, GetResponse and GenerateRaw
+	// call, covering the whole response including retries and reconnects (0 = no deadline)
+	RequestTimeout time.Duration
+	// TotalTimeout limits a whole streamed response like RequestTimeout, the shorter of
+	// both applies (0 = unlimited).
+	//
+	// Deprecated: use RequestTimeout.
+	TotalTimeout time.Duration
+
+	// EventBus receives the deltas of StreamResponseDelta (nil = no events)
+	EventBus *EventBus
+
+	// UserAgent is sent with every HTTP request (empty = DefaultUserAgent)
+	UserAgent string
+
+	// HTTPClientFactory replaces the HTTP client used for all API calls (nil = default clients)
+	HTTPClientFactory HTTPClientFactory
 }
 
+// HTTPClientFactory creates the HTTP client used by the LLM client
+type HTTPClientFactory func() *http.Client
+
 // Stats holds token and timing statistics for LLM interactions
 type Stats struct {
 	InputTokens  int
 	OutputTokens int
 	ThinkingTime time.Duration
 	ResponseTime time.Duration
+	Calls        int
+	// OutputTokensPerSecond is the output throughput over the response time (0 = unknown)
+	OutputTokensPerSecond float64
+	// TimeToFirstToken is the time until the first content was streamed (0 = unknown)
+	TimeToFirstToken time.Duration
+	// SystemFingerprint identifies the backend configuration that generated the response
+	SystemFingerprint string
+}
+
+// UsageSample is the usage reported by a single streaming chunk
+type UsageSample struct {
+	ChunkIndex   int `json:"chunk_index" yaml:"chunk_index"`
+	InputTokens  int `json:"input_tokens" yaml:"input_tokens"`
+	OutputTokens int `json:"output_tokens" yaml:"output_tokens"`
 }
 
 // NewClient creates a new LLM client with the given configuration
 func NewClient(config Config) *Client {
+	if config.SystemPromptRole == "" {
+		config.SystemPromptRole = DefaultSystemPromptRole(config.Model)
+	}
+	if config.UserAgent == "" {
+		config.UserAgent = DefaultUserAgent()
+	}
+	if config.ThinkStartTag == "" {
+		config.ThinkStartTag = DefaultThinkStartTag
+	}
+	if config.ThinkEndTag == "" {
+		config.ThinkEndTag = DefaultThinkEndTag
+	}
+	c := &Client{
+		config:        config,
+		transport:     newTransport(config.ConnectTimeout),
+		ollamaBaseURL: strings.TrimRight(strings.TrimSuffix(strings.TrimRight(config.BaseURL, "/"), "/v1"), "/"),
+		sessionStart:  time.Now(),
+	}
 	client := openai.NewClient(
 		option.WithAPIKey(config.APIKey),
 		option.WithBaseURL(config.BaseURL),
+		option.WithHTTPClient(c.httpClient(0)),
 	)
+	c.client = &client
+	return c
+}
+
+// GetThinkingTags returns the delimiters of thinking blocks
+func (c *Client) GetThinkingTags() (startTag, endTag string) {
+	return c.config.ThinkStartTag, c.config.ThinkEndTag
+}
+
+// GetOllamaBaseURL returns the base URL of the native Ollama API, BaseURL without /v1
+func (c *Client) GetOllamaBaseURL() string {
+	return c.ollamaBaseURL
+}
 
-	return &Client{
-		client: &client,
-		config: config,
+// newTransport returns a transport that limits connection establishment to connectTimeout,
+// or nil to use the default transport when no limit is set
+func newTransport(connectTimeout time.Duration) http.RoundTripper {
+	if connectTimeout <= 0 {
+		return nil
 	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   connectTimeout,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	return transport
 }
 
-// DisplayTokenUsage shows the token usage for the current interaction
-func (c *Client) DisplayTokenUsage() {
+// DefaultSystemPromptRole returns the instruction role expected by the model. OpenAI
+// reasoning models use "developer" instead of "system".
+func DefaultSystemPromptRole(model string) string {
+	if IsReasoningModel(model) {
+		return SystemRoleDeveloper
+	}
+	return SystemRoleSystem
+}
+
+// IsReasoningModel reports whether the model is an OpenAI reasoning model (o1, o3 or o4)
+func IsReasoningModel(model string) bool {
+	for _, prefix := range []string{"o1", "o3", "o4"} {
+		if strings.HasPrefix(model, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// httpClient returns the HTTP client for API calls, using the configured factory when
+// set and a client with the given timeout otherwise, sending the configured User-Agent
+func (c *Client) httpClient(timeout time.Duration) *http.Client {
+	if c.config.HTTPClientFactory != nil {
+		return withUserAgent(c.config.HTTPClientFactory(), c.config.UserAgent)
+	}
+	return withUserAgent(&http.Client{
+		Timeout:   timeout,
+		Transport: c.transport,
+	}, c.config.UserAgent)
+}
+
+// DisplayTokenUsage writes the token usage for the current interaction to w
+func (c *Client) DisplayTokenUsage(w io.Writer) {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
-	fmt.Printf("\nTokens: Input %d | Output %d | Total %d\n",
+	fmt.Fprintf(w, "\nTokens: Input %d | Output %d | Total %d\n",
 		c.currentInputTokens, c.currentOutputTokens,
 		c.currentInputTokens+c.currentOutputTokens)
 
@@ -83,22 +331,31 @@ func (c *Client) DisplayTokenUsage() {
 	if totalTime > 0 {
 		if c.thinkingDuration > 0 || c.responseDuration > 0 {
 			// Show detailed breakdown when thinking is present
-			fmt.Printf("Time: Thinking %v | Response %v | Total %v\n",
-				c.thinkingDuration.Round(time.Millisecond),
-				c.responseDuration.Round(time.Millisecond),
-				(c.thinkingDuration + c.responseDuration).Round(time.Millisecond))
+			fmt.Fprintf(w, "Time: Thinking %s | Response %s | Total %s\n",
+				format.Duration(c.thinkingDuration),
+				format.Duration(c.responseDuration),
+				format.Duration(c.thinkingDuration+c.responseDuration))
 		} else {
 			// Show simple total time when no thinking breakdown
-			fmt.Printf("Time: %v\n", totalTime.Round(time.Millisecond))
+			fmt.Fprintf(w, "Time: %s\n", format.Duration(totalTime))
+		}
+		if speed := c.outputTokensPerSecond(); speed > 0 {
+			fmt.Fprintf(w, "Speed: %.1f tok/s | Avg speed: %.1f tok/s\n", speed, c.tokensPerSecondEWMA)
+		}
+		if ttft := c.timeToFirstToken(); ttft > 0 {
+			fmt.Fprintf(w, "TTFT: %dms\n", ttft.Milliseconds())
 		}
 	}
 }
 
-// DisplayTotalUsage shows the total token usage across all interactions
-func (c *Client) DisplayTotalUsage() {
-	fmt.Printf("\nTotal tokens used: Input %d | Output %d | Combined %d\n",
+// DisplayTotalUsage writes the total token usage across all interactions to w
+func (c *Client) DisplayTotalUsage(w io.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	fmt.Fprintf(w, "\nTotal tokens used: Input %d | Output %d | Combined %d\n",
 		c.totalInputTokens, c.totalOutputTokens,
 		c.totalInputTokens+c.totalOutputTokens)
+	fmt.Fprintf(w, "Total API calls: %d\n", c.totalCalls)
 }
 
 // GetStats returns the current interaction statistics
@@ -110,129 +367,582 @@ func (c *Client) GetStats() Stats {
 		OutputTokens: c.currentOutputTokens,
 		ThinkingTime: c.thinkingDuration,
 		ResponseTime: c.responseDuration,
+		Calls:        c.currentCalls,
+
+		OutputTokensPerSecond: c.outputTokensPerSecond(),
+		TimeToFirstToken:      c.timeToFirstToken(),
+		SystemFingerprint:     c.systemFingerprint,
+	}
+}
+
+// GetUsageTimeline returns the usage reported by each chunk of the current response
+func (c *Client) GetUsageTimeline() []UsageSample {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	timeline := make([]UsageSample, len(c.usageTimeline))
+	copy(timeline, c.usageTimeline)
+	return timeline
+}
+
+// GetTotalStats returns the statistics accumulated across all interactions in the session
+func (c *Client) GetTotalStats() Stats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return Stats{
+		InputTokens:  c.totalInputTokens,
+		OutputTokens: c.totalOutputTokens,
+		ThinkingTime: c.totalThinkingDuration,
+		ResponseTime: c.totalResponseDuration,
+		Calls:        c.totalCalls,
 	}
 }
 
+// ResetTotalStats clears the statistics accumulated across interactions
+func (c *Client) ResetTotalStats() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.totalInputTokens = 0
+	c.totalOutputTokens = 0
+	c.totalThinkingDuration = 0
+	c.totalResponseDuration = 0
+	c.totalCalls = 0
+	c.tokensPerSecondEWMA = 0
+	c.outputTokensEWMA = 0
+	c.turns = nil
+	c.sessionStart = time.Now()
+}
+
+// GetTokensPerSecond returns the moving average of the output throughput across the session
+func (c *Client) GetTokensPerSecond() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.tokensPerSecondEWMA
+}
+
+// outputTokensPerSecond returns the output throughput of the current interaction over
+// its response time. The caller must hold the mutex.
+func (c *Client) outputTokensPerSecond() float64 {
+	seconds := c.responseDuration.Seconds()
+	if seconds <= 0 || c.currentOutputTokens == 0 {
+		return 0
+	}
+	return float64(c.currentOutputTokens) / seconds
+}
+
+// timeToFirstToken returns the time from the start of the current interaction to its
+// first streamed content, or 0 when nothing was streamed. The caller must hold the mutex.
+func (c *Client) timeToFirstToken() time.Duration {
+	if c.firstTokenTime.IsZero() {
+		return 0
+	}
+	return c.firstTokenTime.Sub(c.startTime)
+}
+
+// updateAverages adds the current interaction to the session averages and turns. It is
+// only called for successful interactions. The caller must hold the mutex.
+func (c *Client) updateAverages() {
+	c.turns = append(c.turns, turnUsage{inputTokens: c.currentInputTokens, outputTokens: c.currentOutputTokens})
+	if c.currentOutputTokens > 0 {
+		c.outputTokensEWMA = ewma(c.outputTokensEWMA, float64(c.currentOutputTokens))
+	}
+	if speed := c.outputTokensPerSecond(); speed > 0 {
+		c.tokensPerSecondEWMA = ewma(c.tokensPerSecondEWMA, speed)
+	}
+}
+
+// ewma adds a sample to an exponentially weighted moving average, where 0 means no samples yet
+func ewma(average, sample float64) float64 {
+	if average == 0 {
+		return sample
+	}
+	return ewmaAlpha*sample + (1-ewmaAlpha)*average
+}
+
+// GetCurrentModel returns the model used for completions
+func (c *Client) GetCurrentModel() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.config.Model
+}
+
+// SetModel changes the model used by subsequent calls
+func (c *Client) SetModel(model string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.config.Model = model
+}
+
+// SetSystemPrompt changes the system prompt for subsequent calls. The conversation
+// memory must be updated separately.
+func (c *Client) SetSystemPrompt(prompt string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.config.SystemPrompt = prompt
+}
+
+// GetSystemPrompt returns the configured system prompt
+func (c *Client) GetSystemPrompt() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.config.SystemPrompt
+}
+
+// GetLastResponse returns the full text of the last successful response, including thinking
+func (c *Client) GetLastResponse() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.lastResponse
+}
+
+// GetLastPlainResponse returns the last successful response without its thinking block
+func (c *Client) GetLastPlainResponse() string {
+	return c.removeThinkingBlocks(c.GetLastResponse())
+}
+
+// GetCurrentConfig returns a copy of the client configuration
+func (c *Client) GetCurrentConfig() Config {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.config
+}
+
 // StreamResponse sends a message with conversation history and streams the response
-// while concurrently sending chunks to the provided channel
+// while concurrently sending chunks to the provided channel. When RequestTimeout expires
+// the stream stops and the returned error wraps context.DeadlineExceeded.
 func (c *Client) StreamResponse(messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string) (string, error) {
+	return c.StreamResponseContext(context.Background(), messages, hideThinking, chunkChan)
+}
+
+// StreamResponseContext is StreamResponse with a context for cancellation. When ctx is
+// cancelled, the response received so far is returned along with an error wrapping
+// context.Canceled.
+func (c *Client) StreamResponseContext(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string) (string, error) {
+	ctx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+	response, err := c.streamResponse(ctx, messages, hideThinking, chunkChan)
+	if err = c.timeoutError(ctx, err); errors.Is(err, context.DeadlineExceeded) {
+		return "", err
+	}
+	return response, err
+}
+
+// withRequestTimeout returns ctx limited to RequestTimeout when one is set
+func (c *Client) withRequestTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.config.RequestTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.config.RequestTimeout)
+}
+
+// timeoutError returns an error wrapping context.DeadlineExceeded when a request failed
+// because ctx expired, or err unchanged otherwise
+func (c *Client) timeoutError(ctx context.Context, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("request timed out after %v: %w", c.config.RequestTimeout, context.DeadlineExceeded)
+	}
+	return err
+}
+
+// streamResponse implements StreamResponse with a context for cancellation
+func (c *Client) streamResponse(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string) (string, error) {
 	// Reset current interaction token counts and timing
 	c.mutex.Lock()
 	c.currentInputTokens = 0
 	c.currentOutputTokens = 0
+	c.currentCalls = 0
+	c.usageTimeline = nil
 	c.startTime = time.Now()
+	c.thinkingStart = time.Time{}
 	c.thinkingDuration = 0
+	c.responseStart = time.Time{}
 	c.responseDuration = 0
+	c.firstTokenTime = time.Time{}
+	c.systemFingerprint = ""
 	c.mutex.Unlock()
 
-	// Create streaming chat completion with usage tracking
-	stream := c.client.Chat.Completions.NewStreaming(context.Background(), openai.ChatCompletionNewParams{
-		Model:       c.config.Model,
-		Messages:    messages,
-		Temperature: param.NewOpt(c.config.Temperature),
-		StreamOptions: openai.ChatCompletionStreamOptionsParam{
-			IncludeUsage: param.NewOpt(true),
-		},
-	})
+	if c.config.TotalTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.TotalTimeout)
+		defer cancel()
+	}
+
+	state := &streamState{
+		thinking: thinkingScanner{startTag: c.config.ThinkStartTag, endTag: c.config.ThinkEndTag},
+	}
+	var err error
+	for attempt := 0; ; attempt++ {
+		err = c.streamWithRetry(ctx, resumeMessages(messages, state.fullResponse.String()), hideThinking, chunkChan, state)
+		if err == nil || ctx.Err() != nil || !c.shouldReconnect(err, attempt) {
+			break
+		}
+	}
+
+	// Text held back as a possible tag at the end of the response is content after all
+	for _, piece := range state.thinking.flush() {
+		c.emitPiece(piece, hideThinking, chunkChan, state)
+	}
+
+	// Record final timing when streaming completes
+	c.mutex.Lock()
+	c.endTime = time.Now()
+
+	// Record final duration for active block
+	if !c.thinkingStart.IsZero() {
+		// Still in thinking block at end
+		c.thinkingDuration += time.Since(c.thinkingStart)
+	} else if !c.responseStart.IsZero() {
+		// Still in response block at end
+		c.responseDuration += time.Since(c.responseStart)
+	}
+	c.totalThinkingDuration += c.thinkingDuration
+	c.totalResponseDuration += c.responseDuration
+	// Failed and interrupted responses do not count as turns
+	if err == nil {
+		c.updateAverages()
+	}
+	recordMetrics(c.currentInputTokens, c.currentOutputTokens, c.endTime.Sub(c.startTime), err)
+	c.mutex.Unlock()
+
+	// Close channel if provided
+	if chunkChan != nil {
+		close(chunkChan)
+	}
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return state.fullResponse.String(), fmt.Errorf("response interrupted: %w", context.Canceled)
+		}
+		return "", err
+	}
+
+	c.mutex.Lock()
+	c.lastResponse = state.fullResponse.String()
+	c.mutex.Unlock()
+	return state.fullResponse.String(), nil
+}
+
+// streamState tracks the response across reconnect attempts
+type streamState struct {
+	chunkIndex      int
+	fullResponse    strings.Builder
+	thinking        thinkingScanner
+	responseStarted bool
+}
+
+// emitPiece records the thinking block transitions with timing and sends the piece to
+// chunkChan, unless it is thinking hidden by hideThinking
+func (c *Client) emitPiece(piece thinkingPiece, hideThinking bool, chunkChan chan<- string, state *streamState) {
+	switch {
+	case piece.isStartTag:
+		// Entering thinking block - record response duration so far
+		c.mutex.Lock()
+		if !c.responseStart.IsZero() {
+			c.responseDuration += time.Since(c.responseStart)
+			c.responseStart = time.Time{} // Reset for next response segment
+		}
+		c.thinkingStart = time.Now()
+		c.mutex.Unlock()
+	case piece.isEndTag:
+		// Exiting thinking block - record thinking duration
+		c.mutex.Lock()
+		if !c.thinkingStart.IsZero() {
+			c.thinkingDuration += time.Since(c.thinkingStart)
+			c.thinkingStart = time.Time{} // Reset for next thinking segment
+		}
+		c.responseStart = time.Now() // Start timing response after thinking
+		c.mutex.Unlock()
+	}
 
-	var fullResponse strings.Builder
-	var inThinkingBlock bool
-	var responseStarted bool
+	if hideThinking && piece.thinking {
+		return
+	}
+	// Simulate a slow network when configured
+	if c.config.FakeStreamDelay > 0 {
+		time.Sleep(c.config.FakeStreamDelay)
+	}
+	// Send chunk to channel if provided
+	if chunkChan != nil {
+		chunkChan <- piece.text
+	}
+	state.fullResponse.WriteString(piece.text)
+}
+
+// streamAttempt runs a single streaming request and appends the received content to state
+func (c *Client) streamAttempt(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string, state *streamState) error {
+	// Create streaming chat completion with usage tracking
+	c.mutex.Lock()
+	c.currentCalls++
+	c.totalCalls++
+	c.mutex.Unlock()
 
-	for stream.Next() {
+	params := c.buildParams(messages)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{
+		IncludeUsage: param.NewOpt(true),
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := newIdleTimer(c.config.StreamingIdleTimeout, cancel)
+	defer idle.stop()
+
+	stream := c.newChatStream(ctx, params)
+	defer stream.Close()
+
+	for {
+		idle.reset()
+		ok, err := nextChunk(stream)
+		// Time spent handling the chunk does not count as idle
+		idle.stop()
+		if err != nil {
+			return fmt.Errorf("error during streaming: %w", err)
+		}
+		if !ok {
+			break
+		}
 		chunk := stream.Current()
+		chunkIndex := state.chunkIndex
+		state.chunkIndex++
+
+		if chunk.SystemFingerprint != "" {
+			c.mutex.Lock()
+			c.systemFingerprint = chunk.SystemFingerprint
+			c.mutex.Unlock()
+		}
 
 		// Check for usage data in the chunk
 		if chunk.Usage.PromptTokens > 0 {
 			c.mutex.Lock()
-			c.currentInputTokens = int(chunk.Usage.PromptTokens)
-			c.currentOutputTokens = int(chunk.Usage.CompletionTokens)
-			c.totalInputTokens += c.currentInputTokens
-			c.totalOutputTokens += c.currentOutputTokens
+			c.usageTimeline = append(c.usageTimeline, UsageSample{
+				ChunkIndex:   chunkIndex,
+				InputTokens:  int(chunk.Usage.PromptTokens),
+				OutputTokens: int(chunk.Usage.CompletionTokens),
+			})
+			c.currentInputTokens += int(chunk.Usage.PromptTokens)
+			c.currentOutputTokens += int(chunk.Usage.CompletionTokens)
+			c.totalInputTokens += int(chunk.Usage.PromptTokens)
+			c.totalOutputTokens += int(chunk.Usage.CompletionTokens)
 			c.mutex.Unlock()
 		}
 
-		if len(chunk.Choices) == 0 {
+		if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+			// Some backends report failures as a regular event after a partial response
+			if msg, ok := inStreamError(chunk); ok {
+				return fmt.Errorf("%w: %s", ErrInStreamError, msg)
+			}
 			continue
 		}
 		delta := chunk.Choices[0].Delta
-		if delta.Content == "" {
-			continue
-		}
 		text := delta.Content
 
+		c.mutex.Lock()
+		if c.firstTokenTime.IsZero() {
+			c.firstTokenTime = time.Now()
+		}
+		c.mutex.Unlock()
+
 		// Start timing the first non-empty response content
-		if !responseStarted && text != "" {
+		if !state.responseStarted && text != "" {
 			c.mutex.Lock()
 			if c.responseStart.IsZero() {
 				c.responseStart = time.Now()
 			}
 			c.mutex.Unlock()
-			responseStarted = true
+			state.responseStarted = true
 		}
 
-		// Handle thinking block transitions with timing
-		if !inThinkingBlock && text == startThinkTag {
-			// Entering thinking block - record response duration so far
-			c.mutex.Lock()
-			if !c.responseStart.IsZero() {
-				c.responseDuration += time.Since(c.responseStart)
-				c.responseStart = time.Time{} // Reset for next response segment
-			}
-			c.thinkingStart = time.Now()
-			c.mutex.Unlock()
-			inThinkingBlock = true
+		// Tags may be split over several chunks
+		for _, piece := range state.thinking.scan(text) {
+			c.emitPiece(piece, hideThinking, chunkChan, state)
 		}
+	}
 
-		if inThinkingBlock && text == endThinkTag {
-			// Exiting thinking block - record thinking duration
-			c.mutex.Lock()
-			if !c.thinkingStart.IsZero() {
-				c.thinkingDuration += time.Since(c.thinkingStart)
-				c.thinkingStart = time.Time{} // Reset for next thinking segment
-			}
-			c.responseStart = time.Now() // Start timing response after thinking
-			c.mutex.Unlock()
-			inThinkingBlock = false
-			if hideThinking {
-				continue
-			}
+	if err := stream.Err(); err != nil {
+		if idle.expired() {
+			return fmt.Errorf("%w: no data received for %v", ErrStreamingIdleTimeout, c.config.StreamingIdleTimeout)
 		}
-
-		if !hideThinking || !inThinkingBlock {
-			// Not hiding thinking - send everything
-			// Send chunk to channel if provided
-			if chunkChan != nil {
-				chunkChan <- text
-			}
-			fullResponse.WriteString(text)
+		// The SDK reports error events with an untyped error, so match on its message
+		if msg, ok := strings.CutPrefix(err.Error(), sdkStreamErrorPrefix); ok {
+			return fmt.Errorf("%w: %s", ErrInStreamError, msg)
 		}
+		return fmt.Errorf("error during streaming: %w", err)
 	}
+	return nil
+}
 
-	// Record final timing when streaming completes
-	c.mutex.Lock()
-	c.endTime = time.Now()
+// idleTimer cancels a stream when no chunk arrives within the timeout
+type idleTimer struct {
+	timeout time.Duration
+	timer   *time.Timer
+	fired   atomic.Bool
+}
 
-	// Record final duration for active block
-	if !c.thinkingStart.IsZero() {
-		// Still in thinking block at end
-		c.thinkingDuration += time.Since(c.thinkingStart)
-	} else if !c.responseStart.IsZero() {
-		// Still in response block at end
-		c.responseDuration += time.Since(c.responseStart)
+// newIdleTimer creates a stopped idle timer calling cancel on expiry; a zero timeout disables it
+func newIdleTimer(timeout time.Duration, cancel context.CancelFunc) *idleTimer {
+	t := &idleTimer{timeout: timeout}
+	if timeout > 0 {
+		t.timer = time.AfterFunc(timeout, func() {
+			t.fired.Store(true)
+			cancel()
+		})
+		t.timer.Stop()
 	}
-	c.mutex.Unlock()
+	return t
+}
 
-	// Close channel if provided
-	if chunkChan != nil {
-		close(chunkChan)
+// reset restarts the timeout while waiting for the next chunk
+func (t *idleTimer) reset() {
+	if t.timer != nil {
+		t.timer.Reset(t.timeout)
 	}
+}
 
-	if err := stream.Err(); err != nil {
-		return "", fmt.Errorf("error during streaming: %w", err)
+// stop pauses the timeout
+func (t *idleTimer) stop() {
+	if t.timer != nil {
+		t.timer.Stop()
 	}
+}
 
-	return fullResponse.String(), nil
+// expired reports whether the timeout cancelled the stream
+func (t *idleTimer) expired() bool {
+	return t.fired.Load()
+}
+
+// buildParams creates the chat completion request parameters from the client configuration
+func (c *Client) buildParams(messages []openai.ChatCompletionMessageParamUnion) openai.ChatCompletionNewParams {
+	params := openai.ChatCompletionNewParams{
+		Model:       c.GetCurrentModel(),
+		Messages:    messages,
+		Temperature: param.NewOpt(c.config.Temperature),
+	}
+	if len(c.config.StopSequences) > 0 {
+		params.Stop = openai.ChatCompletionNewParamsStopUnion{OfStringArray: c.config.StopSequences}
+	}
+	if c.config.Seed != nil {
+		params.Seed = param.NewOpt(*c.config.Seed)
+	}
+	if c.config.TopP != 0 {
+		params.TopP = param.NewOpt(c.config.TopP)
+	}
+	if c.config.PresencePenalty != 0 {
+		params.PresencePenalty = param.NewOpt(c.config.PresencePenalty)
+	}
+	if c.config.FrequencyPenalty != 0 {
+		params.FrequencyPenalty = param.NewOpt(c.config.FrequencyPenalty)
+	}
+	if c.config.MaxTokens > 0 {
+		params.MaxCompletionTokens = param.NewOpt(int64(c.config.MaxTokens))
+	}
+	if c.config.ReasoningEffort != "" {
+		params.ReasoningEffort = shared.ReasoningEffort(c.config.ReasoningEffort)
+	}
+	if c.config.ResponseSchema != nil {
+		params.ResponseFormat = responseFormat(c.config.ResponseSchema)
+	}
+	if c.config.NumCtx > 0 {
+		// Ollama-specific model options, ignored by other backends
+		params.SetExtraFields(map[string]any{
+			"options": map[string]any{"num_ctx": c.config.NumCtx},
+		})
+	}
+	return params
+}
+
+// responseFormat builds the structured output format for a JSON schema, named after
+// the schema title when it has one
+func responseFormat(schema json.RawMessage) openai.ChatCompletionNewParamsResponseFormatUnion {
+	var meta struct {
+		Title string `json:"title"`
+	}
+	_ = json.Unmarshal(schema, &meta)
+	name := schemaName(meta.Title)
+	if name == "" {
+		name = "response"
+	}
+
+	return openai.ChatCompletionNewParamsResponseFormatUnion{
+		OfJSONSchema: &shared.ResponseFormatJSONSchemaParam{
+			JSONSchema: shared.ResponseFormatJSONSchemaJSONSchemaParam{
+				Name:   name,
+				Strict: param.NewOpt(true),
+				Schema: schema,
+			},
+		},
+	}
+}
+
+// schemaName turns a schema title into a valid json_schema name, which may only contain
+// up to 64 letters, digits, underscores and dashes
+func schemaName(title string) string {
+	name := invalidSchemaNameChars.ReplaceAllString(title, "_")
+	if len(name) > maxSchemaNameLength {
+		name = name[:maxSchemaNameLength]
+	}
+	return name
+}
+
+// shouldReconnect reports whether a failed attempt was a dropped connection that may be retried
+func (c *Client) shouldReconnect(err error, attempt int) bool {
+	if !c.config.ReconnectOnDrop {
+		return false
+	}
+	maxReconnects := c.config.MaxReconnects
+	if maxReconnects <= 0 {
+		maxReconnects = defaultMaxReconnects
+	}
+	if attempt >= maxReconnects {
+		return false
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, net.ErrClosed)
+}
+
+// resumeMessages asks the model to continue a partial response received before the
+// connection dropped, so already displayed content is not streamed again
+func resumeMessages(messages []openai.ChatCompletionMessageParamUnion, partial string) []openai.ChatCompletionMessageParamUnion {
+	if partial == "" {
+		return messages
+	}
+	resumed := make([]openai.ChatCompletionMessageParamUnion, 0, len(messages)+2)
+	resumed = append(resumed, messages...)
+	resumed = append(resumed,
+		openai.AssistantMessage(partial),
+		openai.UserMessage(resumeInstruction),
+	)
+	return resumed
+}
+
+// inStreamError extracts the error message from a chunk that carries an error payload
+func inStreamError(chunk openai.ChatCompletionChunk) (string, bool) {
+	var payload struct {
+		Error json.RawMessage `json:"error"`
+		Event string          `json:"event"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal([]byte(chunk.RawJSON()), &payload); err != nil {
+		return "", false
+	}
+
+	switch {
+	case len(payload.Error) > 0 && string(payload.Error) != "null":
+		return errorMessage(payload.Error), true
+	case payload.Event == "error":
+		return errorMessage(payload.Data), true
+	}
+	return "", false
+}
+
+// errorMessage returns a readable message from a string or {"message": ...} error value
+func errorMessage(raw json.RawMessage) string {
+	var msg string
+	if err := json.Unmarshal(raw, &msg); err == nil {
+		return msg
+	}
+	var obj struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(raw, &obj); err == nil && obj.Message != "" {
+		return obj.Message
+	}
+	return string(raw)
 }
 
 // GetModelInfo retrieves detailed information about the specified model
@@ -247,9 +957,7 @@ func (c *Client) GetModelInfo(model string) (*openai.Model, error) {
 
 // DisplayModelInfo shows detailed information about the model using Ollama API
 func (c *Client) DisplayModelInfo() error {
-	// Convert OpenAI BaseURL to Ollama BaseURL by removing /v1 suffix if present
-	ollamaBaseURL := strings.TrimSuffix(c.config.BaseURL, "/v1")
-	info, err := GetOllamaModelInfo(ollamaBaseURL, c.config.APIKey, c.config.Model)
+	info, err := c.GetOllamaModelInfo(c.GetCurrentModel())
 	if err != nil {
 		return err
 	}
@@ -259,5 +967,8 @@ func (c *Client) DisplayModelInfo() error {
 		return fmt.Errorf("failed to marshal model info: %w", err)
 	}
 	fmt.Println(string(jsonData))
+	if !info.ModifiedAt.IsZero() {
+		fmt.Printf("Last modified: %s\n", info.ModifiedAt.Local().Format(time.RFC1123))
+	}
 	return nil
 }
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+// benchChunks is the number of content chunks of the canned benchmark stream
+const benchChunks = 100
+
+// chunkEvent returns the SSE event of a chat completion chunk carrying content
+func chunkEvent(content string) string {
+	return fmt.Sprintf(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":0,"model":"test","choices":[{"index":0,"delta":{"content":%q}}]}`+"\n\n", content)
+}
+
+// usageEvent returns the SSE event of the final chunk reporting the token usage
+func usageEvent(input, output int) string {
+	return fmt.Sprintf(`data: {"id":"chatcmpl-1","object":"chat.completion.chunk","created":0,"model":"test","choices":[],"usage":{"prompt_tokens":%d,"completion_tokens":%d,"total_tokens":%d}}`+"\n\n", input, output, input+output)
+}
+
+// newSSEServer starts a server answering every chat completion with the given SSE events,
+// followed by the [DONE] marker
+func newSSEServer(tb testing.TB, events []string) *httptest.Server {
+	tb.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprint(w, event)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	tb.Cleanup(server.Close)
+	return server
+}
+
+// newTestClient creates a client sending its requests to server
+func newTestClient(server *httptest.Server) *Client {
+	return NewClient(Config{
+		APIKey:  "test",
+		BaseURL: server.URL,
+		Model:   "test",
+	})
+}
+
+func BenchmarkStreamResponse(b *testing.B) {
+	events := []string{chunkEvent(DefaultThinkStartTag)}
+	for i := range benchChunks {
+		if i == benchChunks/2 {
+			events = append(events, chunkEvent(DefaultThinkEndTag))
+		}
+		events = append(events, chunkEvent(fmt.Sprintf("token%d ", i)))
+	}
+	events = append(events, usageEvent(10, benchChunks))
+	client := newTestClient(newSSEServer(b, events))
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Hello")}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		if _, err := client.StreamResponse(messages, true, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseThinkingBlocks(b *testing.B) {
+	var response strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&response, "%sStep %d: %s%s\nAnswer part %d. ",
+			DefaultThinkStartTag, i, strings.Repeat("reasoning ", 50), DefaultThinkEndTag, i)
+	}
+	s := response.String()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		SplitThinking(s, DefaultThinkStartTag, DefaultThinkEndTag)
+	}
+}
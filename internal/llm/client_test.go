@@ -0,0 +1,73 @@
+package llm
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+func TestSchemaName(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"colors", "colors"},
+		{"Primary Colors", "Primary_Colors"},
+		{"user-profile_v2", "user-profile_v2"},
+		{"Größe/Gewicht", "Gr__e_Gewicht"},
+		{strings.Repeat("a", 80), strings.Repeat("a", 64)},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := schemaName(tt.title); got != tt.want {
+			t.Errorf("schemaName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestDefaultSystemPromptRole(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"o1-mini", SystemRoleDeveloper},
+		{"o3", SystemRoleDeveloper},
+		{"o4-mini", SystemRoleDeveloper},
+		{"gpt-4o", SystemRoleSystem},
+		{"qwen3:8b", SystemRoleSystem},
+	}
+	for _, tt := range tests {
+		if got := DefaultSystemPromptRole(tt.model); got != tt.want {
+			t.Errorf("DefaultSystemPromptRole(%q) = %q, want %q", tt.model, got, tt.want)
+		}
+		if got, want := IsReasoningModel(tt.model), tt.want == SystemRoleDeveloper; got != want {
+			t.Errorf("IsReasoningModel(%q) = %v, want %v", tt.model, got, want)
+		}
+	}
+}
+
+func TestDisplayUsageWriter(t *testing.T) {
+	client := newTestClient(newSSEServer(t, []string{chunkEvent("4"), usageEvent(10, 20)}))
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("What is 2+2?")}
+	if _, err := client.StreamResponse(messages, false, nil); err != nil {
+		t.Fatalf("StreamResponse() error = %v", err)
+	}
+
+	var turn strings.Builder
+	client.DisplayTokenUsage(&turn)
+	if want := "Tokens: Input 10 | Output 20 | Total 30\n"; !strings.Contains(turn.String(), want) {
+		t.Errorf("DisplayTokenUsage() wrote %q, want it to contain %q", turn.String(), want)
+	}
+	if !strings.Contains(turn.String(), "Time: ") {
+		t.Errorf("DisplayTokenUsage() wrote %q, want a time line", turn.String())
+	}
+
+	var total strings.Builder
+	client.DisplayTotalUsage(&total)
+	want := "\nTotal tokens used: Input 10 | Output 20 | Combined 30\nTotal API calls: 1\n"
+	if total.String() != want {
+		t.Errorf("DisplayTotalUsage() wrote %q, want %q", total.String(), want)
+	}
+}
@@ -0,0 +1,29 @@
+package llm
+
+import "strings"
+
+// defaultTemperature matches the temperature used by the command line when none is set
+const defaultTemperature = 0.7
+
+// NewClientFromURL creates a client for an OpenAI-compatible API with default settings
+func NewClientFromURL(apiKey, baseURL, model string) *Client {
+	return NewClient(Config{
+		APIKey:      apiKey,
+		BaseURL:     strings.TrimRight(baseURL, "/"),
+		Model:       model,
+		Temperature: defaultTemperature,
+	})
+}
+
+// NewOpenAIClient creates a client for the OpenAI API with default settings
+func NewOpenAIClient(apiKey, model string) *Client {
+	return NewClientFromURL(apiKey, "https://api.openai.com/v1", model)
+}
+
+// NewOllamaClient creates a client for the Ollama server at host, e.g. "localhost:11434"
+func NewOllamaClient(host, model string) *Client {
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	return NewClientFromURL("", strings.TrimRight(host, "/")+"/v1", model)
+}
@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/openai/openai-go"
+)
+
+// ErrUnknownModelPrice is returned when no price is known for the model
+var ErrUnknownModelPrice = errors.New("no price known for model")
+
+// Approximation used by CountTokens: characters per token and per-message overhead
+const (
+	charsPerToken         = 4
+	tokensPerMessage      = 4
+	defaultOutputEstimate = 500
+)
+
+// modelPrice is the USD price per million tokens
+type modelPrice struct {
+	input  float64
+	output float64
+}
+
+// modelPrices lists the prices of common models, matched by model name prefix
+var modelPrices = map[string]modelPrice{
+	"gpt-4o":           {input: 2.50, output: 10.00},
+	"gpt-4o-mini":      {input: 0.15, output: 0.60},
+	"gpt-4.1":          {input: 2.00, output: 8.00},
+	"gpt-4.1-mini":     {input: 0.40, output: 1.60},
+	"gpt-4.1-nano":     {input: 0.10, output: 0.40},
+	"o1":               {input: 15.00, output: 60.00},
+	"o3":               {input: 2.00, output: 8.00},
+	"o3-mini":          {input: 1.10, output: 4.40},
+	"o4-mini":          {input: 1.10, output: 4.40},
+	"gemini-2.0-flash": {input: 0.10, output: 0.40},
+}
+
+// CountTokens approximates the number of input tokens of the messages
+func CountTokens(messages []openai.ChatCompletionMessageParamUnion) (int, error) {
+	tokens := 0
+	for _, message := range messages {
+		text, err := MessageText(message)
+		if err != nil {
+			return 0, fmt.Errorf("failed to count tokens: %w", err)
+		}
+		tokens += tokensPerMessage + (utf8.RuneCountInString(text)+charsPerToken-1)/charsPerToken
+	}
+	return tokens, nil
+}
+
+// EstimateRequestCost estimates the USD cost of sending the messages, using the average
+// output length of previous responses in the session
+func (c *Client) EstimateRequestCost(messages []openai.ChatCompletionMessageParamUnion) (float64, error) {
+	model := c.GetCurrentModel()
+	price, ok := lookupModelPrice(model)
+	if !ok {
+		return 0, fmt.Errorf("%w: %s", ErrUnknownModelPrice, model)
+	}
+
+	inputTokens, err := CountTokens(messages)
+	if err != nil {
+		return 0, err
+	}
+
+	c.mutex.Lock()
+	outputTokens := c.outputTokensEWMA
+	c.mutex.Unlock()
+	if outputTokens == 0 {
+		outputTokens = defaultOutputEstimate
+	}
+
+	return (float64(inputTokens)*price.input + outputTokens*price.output) / 1_000_000, nil
+}
+
+// lookupModelPrice returns the price of the longest model prefix matching the model
+func lookupModelPrice(model string) (modelPrice, bool) {
+	var best string
+	for prefix := range modelPrices {
+		if strings.HasPrefix(model, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return modelPrice{}, false
+	}
+	return modelPrices[best], true
+}
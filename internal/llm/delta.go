@@ -0,0 +1,81 @@
+package llm
+
+import (
+	"context"
+
+	"github.com/openai/openai-go"
+)
+
+// Delta is a piece of a streamed response with its position in the full response
+type Delta struct {
+	// Position is the byte offset of Content in the response accumulated so far
+	Position   int
+	Content    string
+	IsThinking bool
+	// Err is set on the last delta when the stream fails
+	Err error
+}
+
+// StreamResponseDelta streams the response as deltas positioned in the full response,
+// for UIs that track the cursor while rendering. The channel is closed when the
+// response is complete or ctx is cancelled. Each delta is also published on
+// Config.EventBus when one is set.
+func (c *Client) StreamResponseDelta(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, hideThinking bool) (<-chan Delta, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	chunkChan := make(chan string)
+	errChan := make(chan error, 1)
+	go func() {
+		_, err := c.streamResponse(ctx, messages, hideThinking, chunkChan)
+		errChan <- err
+	}()
+
+	deltas := make(chan Delta)
+	go func() {
+		defer close(deltas)
+		position := 0
+		inThinking := false
+		cancelled := false
+		for chunk := range chunkChan {
+			if cancelled {
+				// Drain so the stream can finish
+				continue
+			}
+			// Thinking tags are always streamed as chunks of their own
+			if chunk == c.config.ThinkStartTag {
+				inThinking = true
+			}
+			delta := Delta{Position: position, Content: chunk, IsThinking: inThinking}
+			if chunk == c.config.ThinkEndTag {
+				inThinking = false
+			}
+			position += len(chunk)
+			c.publish(delta)
+
+			select {
+			case deltas <- delta:
+			case <-ctx.Done():
+				cancelled = true
+			}
+		}
+
+		if err := <-errChan; err != nil && !cancelled {
+			delta := Delta{Position: position, Err: err}
+			c.publish(delta)
+			select {
+			case deltas <- delta:
+			case <-ctx.Done():
+			}
+		}
+	}()
+	return deltas, nil
+}
+
+// publish sends the delta to the configured event bus, if any
+func (c *Client) publish(delta Delta) {
+	if c.config.EventBus != nil {
+		c.config.EventBus.Publish(delta)
+	}
+}
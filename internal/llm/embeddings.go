@@ -0,0 +1,94 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// Retry settings for failed embedding batches
+const (
+	embeddingAttempts   = 3
+	embeddingRetryDelay = 500 * time.Millisecond
+)
+
+// BatchEmbeddings computes the embeddings of texts in requests of at most batchSize texts
+// and returns them in input order. onProgress, if set, is called after each batch with the
+// number of completed texts. When ctx is cancelled the embeddings completed so far are
+// returned along with the context error.
+func (c *Client) BatchEmbeddings(ctx context.Context, texts []string, batchSize int, onProgress func(completed, total int)) ([][]float64, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("invalid batch size %d: must be positive", batchSize)
+	}
+
+	embeddings := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += batchSize {
+		end := min(start+batchSize, len(texts))
+		batch, err := c.embedBatch(ctx, texts[start:end])
+		if err != nil {
+			if ctx.Err() != nil {
+				return embeddings, ctx.Err()
+			}
+			return embeddings, fmt.Errorf("failed to embed texts %d-%d: %w", start, end-1, err)
+		}
+		embeddings = append(embeddings, batch...)
+		if onProgress != nil {
+			onProgress(len(embeddings), len(texts))
+		}
+	}
+	return embeddings, nil
+}
+
+// embedBatch sends a single embeddings request, retrying failed attempts
+func (c *Client) embedBatch(ctx context.Context, texts []string) ([][]float64, error) {
+	params := openai.EmbeddingNewParams{
+		Input: openai.EmbeddingNewParamsInputUnion{OfArrayOfStrings: texts},
+		Model: openai.EmbeddingModel(c.embeddingModel()),
+	}
+
+	var err error
+	for attempt := 1; attempt <= embeddingAttempts; attempt++ {
+		var response *openai.CreateEmbeddingResponse
+		response, err = c.client.Embeddings.New(ctx, params)
+		if err == nil {
+			return orderEmbeddings(response.Data, len(texts))
+		}
+		if attempt == embeddingAttempts {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(embeddingRetryDelay * time.Duration(attempt)):
+		}
+	}
+	return nil, err
+}
+
+// orderEmbeddings places the returned embeddings at their input index
+func orderEmbeddings(data []openai.Embedding, count int) ([][]float64, error) {
+	if len(data) != count {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", count, len(data))
+	}
+	ordered := make([][]float64, count)
+	for _, embedding := range data {
+		if embedding.Index < 0 || int(embedding.Index) >= count {
+			return nil, fmt.Errorf("embedding index %d out of range", embedding.Index)
+		}
+		ordered[embedding.Index] = embedding.Embedding
+	}
+	return ordered, nil
+}
+
+// embeddingModel returns the model used for embeddings, defaulting to the chat model
+func (c *Client) embeddingModel() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.config.EmbeddingModel != "" {
+		return c.config.EmbeddingModel
+	}
+	return c.config.Model
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// Event is a streaming event published on an EventBus, one per delta of a streamed response
+type Event = Delta
+
+// EventBus delivers every published event to all subscribers, each through its own channel
+type EventBus struct {
+	mutex       sync.RWMutex
+	subscribers []chan Event
+	bufferSize  int
+	closed      bool
+	// dropped counts the events not delivered to a subscriber whose buffer was full
+	dropped atomic.Int64
+}
+
+// NewEventBus creates an event bus whose subscriber channels buffer bufferSize events
+func NewEventBus(bufferSize int) *EventBus {
+	return &EventBus{bufferSize: max(bufferSize, 0)}
+}
+
+// Subscribe returns a channel receiving the events published from now on. The channel is
+// closed by Close. Publish never waits for a subscriber: events arriving while its buffer
+// is full are dropped and counted by Dropped, so size the buffer for slow readers.
+func (b *EventBus) Subscribe() <-chan Event {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	ch := make(chan Event, b.bufferSize)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers = append(b.subscribers, ch)
+	return ch
+}
+
+// Publish sends e to every subscriber ready to receive it without blocking, dropping it
+// for the others. It does nothing after Close.
+func (b *EventBus) Publish(e Event) {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if b.closed {
+		return
+	}
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- e:
+		default:
+			b.dropped.Add(1)
+		}
+	}
+}
+
+// Dropped returns the number of events dropped because a subscriber's buffer was full
+func (b *EventBus) Dropped() int64 {
+	return b.dropped.Load()
+}
+
+// Close closes all subscriber channels. Further events are discarded.
+func (b *EventBus) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subscribers {
+		close(ch)
+	}
+	b.subscribers = nil
+}
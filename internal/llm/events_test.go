@@ -0,0 +1,47 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBusPublishDropsForFullSubscribers(t *testing.T) {
+	bus := NewEventBus(2)
+	slow := bus.Subscribe()
+	fast := bus.Subscribe()
+
+	received := make(chan int)
+	go func() {
+		n := 0
+		for range fast {
+			n++
+		}
+		received <- n
+	}()
+
+	done := make(chan struct{})
+	go func() {
+		for i := range 5 {
+			bus.Publish(Event{Position: i, Content: "x"})
+			// Let the fast subscriber keep up
+			time.Sleep(time.Millisecond)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a subscriber that does not read")
+	}
+	bus.Close()
+
+	if n := len(slow); n != 2 {
+		t.Errorf("slow subscriber buffered %d events, want 2", n)
+	}
+	if n := <-received; n != 5 {
+		t.Errorf("fast subscriber received %d events, want 5", n)
+	}
+	if got := bus.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+}
@@ -0,0 +1,102 @@
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/openai/openai-go"
+)
+
+// MessageRole returns the role of a message, or an empty string if the message is empty.
+// The role fields of messages built with the SDK constructors are only filled when
+// encoded, so the role is derived from the message variant.
+func MessageRole(message openai.ChatCompletionMessageParamUnion) string {
+	switch {
+	case message.OfDeveloper != nil:
+		return SystemRoleDeveloper
+	case message.OfSystem != nil:
+		return SystemRoleSystem
+	case message.OfUser != nil:
+		return "user"
+	case message.OfAssistant != nil:
+		return "assistant"
+	case message.OfTool != nil:
+		return "tool"
+	case message.OfFunction != nil:
+		return "function"
+	}
+	return ""
+}
+
+// MessageText returns the text content of a message. Content parts are concatenated and
+// non-text parts are included as their JSON encoding.
+func MessageText(message openai.ChatCompletionMessageParamUnion) (string, error) {
+	switch content := message.GetContent().AsAny().(type) {
+	case *string:
+		return *content, nil
+	case *[]openai.ChatCompletionContentPartTextParam:
+		var text strings.Builder
+		for _, part := range *content {
+			text.WriteString(part.Text)
+		}
+		return text.String(), nil
+	case *[]openai.ChatCompletionContentPartUnionParam:
+		var text strings.Builder
+		for _, part := range *content {
+			if part.OfText != nil {
+				text.WriteString(part.OfText.Text)
+				continue
+			}
+			if err := writeJSON(&text, part); err != nil {
+				return "", err
+			}
+		}
+		return text.String(), nil
+	case *[]openai.ChatCompletionAssistantMessageParamContentArrayOfContentPartUnion:
+		var text strings.Builder
+		for _, part := range *content {
+			if part.OfText != nil {
+				text.WriteString(part.OfText.Text)
+				continue
+			}
+			if err := writeJSON(&text, part); err != nil {
+				return "", err
+			}
+		}
+		return text.String(), nil
+	default:
+		return "", nil
+	}
+}
+
+// writeJSON appends the JSON encoding of v to b
+func writeJSON(b *strings.Builder, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode message content: %w", err)
+	}
+	b.Write(data)
+	return nil
+}
+
+// EncodeMessages returns a deterministic key for a conversation by hashing each message as
+// "role:content" lines. Identical conversations always produce identical keys.
+func EncodeMessages(messages []openai.ChatCompletionMessageParamUnion) (string, error) {
+	hash := sha256.New()
+	for i, message := range messages {
+		role := MessageRole(message)
+		if role == "" {
+			return "", fmt.Errorf("message %d has no role", i)
+		}
+		text, err := MessageText(message)
+		if err != nil {
+			return "", err
+		}
+		// Quote the content so embedded newlines cannot shift message boundaries
+		fmt.Fprintf(hash, "%s:%q\n", role, text)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,65 @@
+package llm
+
+import (
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+func TestEncodeMessages(t *testing.T) {
+	conversation := func() []openai.ChatCompletionMessageParamUnion {
+		return []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are terse."),
+			openai.UserMessage("What is 2+2?"),
+			openai.AssistantMessage("4"),
+		}
+	}
+	key, err := EncodeMessages(conversation())
+	if err != nil {
+		t.Fatalf("EncodeMessages() error = %v", err)
+	}
+
+	same, err := EncodeMessages(conversation())
+	if err != nil {
+		t.Fatalf("EncodeMessages() error = %v", err)
+	}
+	if same != key {
+		t.Errorf("identical conversations got keys %q and %q", key, same)
+	}
+
+	tests := []struct {
+		name     string
+		messages []openai.ChatCompletionMessageParamUnion
+	}{
+		{"content", []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are terse."),
+			openai.UserMessage("What is 2+3?"),
+			openai.AssistantMessage("4"),
+		}},
+		{"order", []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are terse."),
+			openai.AssistantMessage("4"),
+			openai.UserMessage("What is 2+2?"),
+		}},
+		{"role", []openai.ChatCompletionMessageParamUnion{
+			openai.DeveloperMessage("You are terse."),
+			openai.UserMessage("What is 2+2?"),
+			openai.AssistantMessage("4"),
+		}},
+		{"message boundaries", []openai.ChatCompletionMessageParamUnion{
+			openai.SystemMessage("You are terse.\nuser:What is 2+2?"),
+			openai.AssistantMessage("4"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := EncodeMessages(tt.messages)
+			if err != nil {
+				t.Fatalf("EncodeMessages() error = %v", err)
+			}
+			if got == key {
+				t.Errorf("changed %s got the same key %q", tt.name, got)
+			}
+		})
+	}
+}
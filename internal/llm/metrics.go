@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"expvar"
+	"net/http"
+	"time"
+)
+
+// Metrics published under "llm_go" through expvar, updated by every streamed response
+var (
+	metricsRequests     = new(expvar.Int)
+	metricsInputTokens  = new(expvar.Int)
+	metricsOutputTokens = new(expvar.Int)
+	metricsErrors       = new(expvar.Int)
+	metricsLastLatency  = new(expvar.Float)
+)
+
+func init() {
+	metrics := expvar.NewMap("llm_go")
+	metrics.Set("total_requests", metricsRequests)
+	metrics.Set("total_input_tokens", metricsInputTokens)
+	metrics.Set("total_output_tokens", metricsOutputTokens)
+	metrics.Set("total_errors", metricsErrors)
+	metrics.Set("last_request_latency_ms", metricsLastLatency)
+}
+
+// recordMetrics adds a streamed response to the expvar metrics
+func recordMetrics(inputTokens, outputTokens int, latency time.Duration, err error) {
+	metricsRequests.Add(1)
+	metricsInputTokens.Add(int64(inputTokens))
+	metricsOutputTokens.Add(int64(outputTokens))
+	if err != nil {
+		metricsErrors.Add(1)
+	}
+	metricsLastLatency.Set(float64(latency) / float64(time.Millisecond))
+}
+
+// MetricsHandler returns a handler serving the expvar metrics on /metrics and /debug/vars
+func MetricsHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", expvar.Handler())
+	mux.Handle("/debug/vars", expvar.Handler())
+	return mux
+}
@@ -0,0 +1,112 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/openai/openai-go"
+)
+
+// ErrNoMoreResponses is returned by MockClient when all scripted responses were used
+var ErrNoMoreResponses = errors.New("no more scripted responses")
+
+// TestingT is the subset of testing.TB used by MockClient assertions
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...any)
+}
+
+// mockResponse is a scripted response replayed by MockClient
+type mockResponse struct {
+	response string
+	stats    Stats
+}
+
+// MockClient is a ClientInterface that replays scripted responses without calling an API
+type MockClient struct {
+	responses []mockResponse
+	calls     int
+	current   Stats
+	total     Stats
+	mutex     sync.Mutex
+}
+
+// NewMockClient creates a mock client without scripted responses
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// AddResponse appends a response and its statistics to the replay script
+func (m *MockClient) AddResponse(response string, stats Stats) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.responses = append(m.responses, mockResponse{response: response, stats: stats})
+}
+
+// StreamResponse replays the next scripted response as a single chunk
+func (m *MockClient) StreamResponse(messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string) (string, error) {
+	if chunkChan != nil {
+		defer close(chunkChan)
+	}
+
+	m.mutex.Lock()
+	if m.calls >= len(m.responses) {
+		m.calls++
+		m.mutex.Unlock()
+		return "", ErrNoMoreResponses
+	}
+	next := m.responses[m.calls]
+	m.calls++
+	m.current = next.stats
+	m.total.InputTokens += next.stats.InputTokens
+	m.total.OutputTokens += next.stats.OutputTokens
+	m.total.ThinkingTime += next.stats.ThinkingTime
+	m.total.ResponseTime += next.stats.ResponseTime
+	m.total.Calls += next.stats.Calls
+	m.mutex.Unlock()
+
+	if chunkChan != nil {
+		chunkChan <- next.response
+	}
+	return next.response, nil
+}
+
+// DisplayTokenUsage writes the token usage of the last replayed response to w
+func (m *MockClient) DisplayTokenUsage(w io.Writer) {
+	stats := m.GetStats()
+	fmt.Fprintf(w, "\nTokens: Input %d | Output %d | Total %d\n",
+		stats.InputTokens, stats.OutputTokens, stats.InputTokens+stats.OutputTokens)
+}
+
+// DisplayTotalUsage writes the token usage accumulated over all replayed responses to w
+func (m *MockClient) DisplayTotalUsage(w io.Writer) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	fmt.Fprintf(w, "\nTotal tokens used: Input %d | Output %d | Combined %d\n",
+		m.total.InputTokens, m.total.OutputTokens, m.total.InputTokens+m.total.OutputTokens)
+	fmt.Fprintf(w, "Total API calls: %d\n", m.total.Calls)
+}
+
+// GetStats returns the statistics of the last replayed response
+func (m *MockClient) GetStats() Stats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.current
+}
+
+// CallCount returns the number of StreamResponse calls, including failed ones
+func (m *MockClient) CallCount() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.calls
+}
+
+// AssertCallCount reports a test failure when StreamResponse was not called n times
+func (m *MockClient) AssertCallCount(t TestingT, n int) {
+	t.Helper()
+	if calls := m.CallCount(); calls != n {
+		t.Errorf("expected %d StreamResponse calls, got %d", n, calls)
+	}
+}
@@ -0,0 +1,132 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// generateChunk is a single NDJSON event of the Ollama /api/generate stream
+type generateChunk struct {
+	Response        string `json:"response"`
+	Done            bool   `json:"done"`
+	Error           string `json:"error"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+}
+
+// GenerateRaw sends the prompt as raw text completion (no chat template) to the Ollama
+// /api/generate endpoint and streams the generated text to progressFn. When RequestTimeout
+// expires the returned error wraps context.DeadlineExceeded.
+func (c *Client) GenerateRaw(ctx context.Context, prompt string, progressFn func(string)) (string, error) {
+	ctx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+	response, err := c.generateRaw(ctx, prompt, progressFn)
+	return response, c.timeoutError(ctx, err)
+}
+
+// generateRaw implements GenerateRaw
+func (c *Client) generateRaw(ctx context.Context, prompt string, progressFn func(string)) (string, error) {
+	// Reset current interaction token counts and timing
+	c.mutex.Lock()
+	c.currentInputTokens = 0
+	c.currentOutputTokens = 0
+	c.currentCalls = 1
+	c.totalCalls++
+	c.startTime = time.Now()
+	c.thinkingDuration = 0
+	c.responseDuration = 0
+	c.firstTokenTime = time.Time{}
+	c.mutex.Unlock()
+
+	// No client timeout - the context carries the request deadline
+	client := c.httpClient(0)
+
+	baseURL := c.GetOllamaBaseURL()
+	generateURL := fmt.Sprintf("%s/api/generate", baseURL)
+
+	options := map[string]interface{}{
+		"temperature": c.config.Temperature,
+	}
+	if c.config.NumCtx > 0 {
+		options["num_ctx"] = c.config.NumCtx
+	}
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"model":   c.GetCurrentModel(),
+		"prompt":  prompt,
+		"raw":     true,
+		"stream":  true,
+		"options": options,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode generate request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", generateURL, strings.NewReader(string(requestBody)))
+	if err != nil {
+		return "", fmt.Errorf("failed to create generate request: %w", err)
+	}
+
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to connect to Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var fullResponse strings.Builder
+	decoder := json.NewDecoder(resp.Body)
+	for {
+		var chunk generateChunk
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fullResponse.String(), fmt.Errorf("error decoding generate response: %w", err)
+		}
+
+		if chunk.Error != "" {
+			return fullResponse.String(), fmt.Errorf("error during generation: %s", chunk.Error)
+		}
+
+		if chunk.Response != "" {
+			if progressFn != nil {
+				progressFn(chunk.Response)
+			}
+			fullResponse.WriteString(chunk.Response)
+		}
+
+		if chunk.Done {
+			c.mutex.Lock()
+			c.currentInputTokens = chunk.PromptEvalCount
+			c.currentOutputTokens = chunk.EvalCount
+			c.totalInputTokens += c.currentInputTokens
+			c.totalOutputTokens += c.currentOutputTokens
+			c.mutex.Unlock()
+			break
+		}
+	}
+
+	// Record final timing when generation completes
+	c.mutex.Lock()
+	c.endTime = time.Now()
+	c.responseDuration = c.endTime.Sub(c.startTime)
+	c.totalResponseDuration += c.responseDuration
+	c.updateAverages()
+	c.mutex.Unlock()
+
+	return fullResponse.String(), nil
+}
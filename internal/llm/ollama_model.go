@@ -3,6 +3,7 @@ package llm
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,24 +15,54 @@ import (
 type OllamaModelInfo struct {
 	Name          string                 `json:"name"`
 	SizeMB        int                    `json:"size_mb"`
+	SizeBytes     int64                  `json:"size_bytes"`
 	Family        string                 `json:"family"`
 	ParameterSize string                 `json:"parameter_size"`
 	Quantization  string                 `json:"quantization"`
 	APIEndpoint   string                 `json:"api_endpoint"`
+	ModifiedAt    time.Time              `json:"modified_at"`
 	Details       map[string]interface{} `json:"details"`
 }
 
-// GetOllamaModelInfo retrieves detailed information about the specified model using Ollama API
-func GetOllamaModelInfo(ollamaBaseURL, apiKey, model string) (*OllamaModelInfo, error) {
-	// Use direct HTTP call with authentication
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
+// PullProgress is a progress event streamed by the Ollama pull and push endpoints
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest,omitempty"`
+	Total     int64  `json:"total,omitempty"`
+	Completed int64  `json:"completed,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
 
-	// Properly format base URL without double slashes
-	baseURL := strings.TrimRight(ollamaBaseURL, "/")
+// RunningModel is a model currently loaded in memory, as listed by the Ollama /api/ps endpoint
+type RunningModel struct {
+	Name      string    `json:"name"`
+	Model     string    `json:"model"`
+	Size      int64     `json:"size"`
+	SizeVRAM  int64     `json:"size_vram"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ErrModelNotFound is returned when the requested model is not available on the server
+var ErrModelNotFound = errors.New("model not found")
+
+// ErrOllamaAPIUnavailable is returned when the server does not provide the Ollama API,
+// as with OpenAI and most other hosted providers
+var ErrOllamaAPIUnavailable = errors.New("Ollama API not available")
+
+// ErrModelNotLoaded is returned when the requested model is not loaded in memory
+var ErrModelNotLoaded = errors.New("model not loaded")
+
+// ollamaTagModel is a single entry of the Ollama /api/tags response
+type ollamaTagModel struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+}
+
+// findOllamaModel looks up the specified model in the Ollama /api/tags listing
+func findOllamaModel(ctx context.Context, client *http.Client, baseURL, apiKey, model string) (*ollamaTagModel, error) {
 	modelsURL := fmt.Sprintf("%s/api/tags", baseURL)
-	req, err := http.NewRequest("GET", modelsURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", modelsURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -55,39 +86,51 @@ func GetOllamaModelInfo(ollamaBaseURL, apiKey, model string) (*OllamaModelInfo,
 
 	// Handle non-200 responses
 	if resp.StatusCode != http.StatusOK {
+		// The listing exists on every Ollama server, whatever models it has
 		if resp.StatusCode == http.StatusNotFound {
-			return nil, fmt.Errorf("model '%s' not found on server", model)
+			return nil, fmt.Errorf("%w at %s", ErrOllamaAPIUnavailable, baseURL)
 		}
 		return nil, fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
 	}
 
 	// Try parsing as JSON regardless of content type
 	var modelsResponse struct {
-		Models []struct {
-			Name string `json:"name"`
-			Size int64  `json:"size"`
-		} `json:"models"`
+		Models []ollamaTagModel `json:"models"`
 	}
 
 	if err := json.Unmarshal(body, &modelsResponse); err != nil {
 		return nil, fmt.Errorf("failed to decode API response: %w. Body: %s", err, string(body))
 	}
 
-	// Find the specific model
-	var modelInfo *struct {
-		Name string `json:"name"`
-		Size int64  `json:"size"`
-	}
-
+	// Find the specific model, where a name without tag means the latest tag
 	for _, m := range modelsResponse.Models {
-		if m.Name == model {
-			modelInfo = &m
-			break
+		if m.Name == model || (!strings.Contains(model, ":") && m.Name == model+":latest") {
+			return &m, nil
 		}
 	}
 
-	if modelInfo == nil {
-		return nil, fmt.Errorf("model '%s' not found on the server", model)
+	return nil, fmt.Errorf("%w: %s", ErrModelNotFound, model)
+}
+
+// GetOllamaModelInfo retrieves detailed information about the specified model using Ollama API.
+//
+// Deprecated: use Client.GetOllamaModelInfo, which sends the configured User-Agent.
+func GetOllamaModelInfo(ollamaBaseURL, apiKey, model string) (*OllamaModelInfo, error) {
+	return NewClient(Config{BaseURL: ollamaBaseURL, APIKey: apiKey}).GetOllamaModelInfo(model)
+}
+
+// GetOllamaModelInfo retrieves detailed information about the specified model using Ollama API
+func (c *Client) GetOllamaModelInfo(model string) (*OllamaModelInfo, error) {
+	return getOllamaModelInfo(c.httpClient(30*time.Second), c.ollamaBaseURL, c.config.APIKey, model)
+}
+
+// getOllamaModelInfo retrieves model information using the given HTTP client
+func getOllamaModelInfo(client *http.Client, ollamaBaseURL, apiKey, model string) (*OllamaModelInfo, error) {
+	// Properly format base URL without double slashes
+	baseURL := strings.TrimRight(ollamaBaseURL, "/")
+	modelInfo, err := findOllamaModel(context.Background(), client, baseURL, apiKey, model)
+	if err != nil {
+		return nil, err
 	}
 
 	detailsURL := fmt.Sprintf("%s/api/show", baseURL)
@@ -142,23 +185,32 @@ func GetOllamaModelInfo(ollamaBaseURL, apiKey, model string) (*OllamaModelInfo,
 	info := &OllamaModelInfo{
 		Name:          modelInfo.Name,
 		SizeMB:        int(modelInfo.Size / (1024 * 1024)),
+		SizeBytes:     modelInfo.Size,
 		Family:        family,
 		ParameterSize: parameterSize,
 		Quantization:  quantization,
 		APIEndpoint:   ollamaBaseURL,
+		ModifiedAt:    modelInfo.ModifiedAt,
 		Details:       detailInfo,
 	}
 
 	return info, nil
 }
 
-// CheckModelExists verifies if a model exists on the Ollama server
+// CheckModelExists verifies if a model exists on the Ollama server.
+//
+// Deprecated: use Client.CheckModelExists, which sends the configured User-Agent.
 func CheckModelExists(ollamaBaseURL, apiKey, model string) (bool, error) {
-	_, err := GetOllamaModelInfo(ollamaBaseURL, apiKey, model)
+	return NewClient(Config{BaseURL: ollamaBaseURL, APIKey: apiKey}).CheckModelExists(model)
+}
+
+// CheckModelExists verifies if a model exists on the Ollama server. The returned error
+// wraps ErrOllamaAPIUnavailable when the server cannot be checked.
+func (c *Client) CheckModelExists(model string) (bool, error) {
+	_, err := c.GetOllamaModelInfo(model)
 	if err != nil {
 		// Check for specific "not found" error
-		if strings.Contains(err.Error(), "model not found") ||
-			strings.Contains(err.Error(), "404") {
+		if errors.Is(err, ErrModelNotFound) {
 			return false, nil
 		}
 		return false, err
@@ -166,16 +218,135 @@ func CheckModelExists(ollamaBaseURL, apiKey, model string) (bool, error) {
 	return true, nil
 }
 
-// PullModel pulls the specified model from the Ollama server
+// GetModelInfoJSON returns the raw /api/show response for the configured model
+func (c *Client) GetModelInfoJSON(ctx context.Context) ([]byte, error) {
+	return c.showModel(ctx, c.GetCurrentModel())
+}
+
+// showModel calls the Ollama /api/show endpoint and returns the raw response body
+func (c *Client) showModel(ctx context.Context, model string) ([]byte, error) {
+	client := c.httpClient(30 * time.Second)
+
+	baseURL := c.GetOllamaBaseURL()
+	showURL := fmt.Sprintf("%s/api/show", baseURL)
+	reqBody := fmt.Sprintf(`{"model":"%s"}`, model)
+	req, err := http.NewRequestWithContext(ctx, "POST", showURL, strings.NewReader(reqBody))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		if resp.StatusCode == http.StatusNotFound {
+			return nil, fmt.Errorf("%w: %s", ErrModelNotFound, model)
+		}
+		return nil, fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// GetModelSize returns the disk size in bytes of the specified model
+func (c *Client) GetModelSize(ctx context.Context, model string) (int64, error) {
+	client := c.httpClient(30 * time.Second)
+
+	baseURL := c.GetOllamaBaseURL()
+	modelInfo, err := findOllamaModel(ctx, client, baseURL, c.config.APIKey, model)
+	if err != nil {
+		return 0, err
+	}
+	return modelInfo.Size, nil
+}
+
+// ListRunningModels returns the models currently loaded by the Ollama server
+func (c *Client) ListRunningModels(ctx context.Context) ([]RunningModel, error) {
+	client := c.httpClient(30 * time.Second)
+
+	baseURL := c.GetOllamaBaseURL()
+	psURL := fmt.Sprintf("%s/api/ps", baseURL)
+	req, err := http.NewRequestWithContext(ctx, "GET", psURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read API response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var psResponse struct {
+		Models []RunningModel `json:"models"`
+	}
+	if err := json.Unmarshal(body, &psResponse); err != nil {
+		return nil, fmt.Errorf("failed to decode API response: %w. Body: %s", err, string(body))
+	}
+	return psResponse.Models, nil
+}
+
+// GetLoadedModelVRAMUsage returns the VRAM in bytes used by the configured model
+func (c *Client) GetLoadedModelVRAMUsage(ctx context.Context) (int64, error) {
+	models, err := c.ListRunningModels(ctx)
+	if err != nil {
+		return 0, err
+	}
+	model := c.GetCurrentModel()
+	for _, m := range models {
+		if m.Name == model || m.Model == model {
+			return m.SizeVRAM, nil
+		}
+	}
+	return 0, fmt.Errorf("%w: %s", ErrModelNotLoaded, model)
+}
+
+// PullModel pulls the specified model from the Ollama server, printing its progress.
+//
+// Deprecated: use Client.PullModel, which sends the configured User-Agent.
 func PullModel(ollamaBaseURL, apiKey, model string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 	defer cancel()
 
-	client := &http.Client{
-		Timeout: 0, // No timeout - we use context for cancellation
-	}
+	lastStatus := ""
+	return NewClient(Config{BaseURL: ollamaBaseURL, APIKey: apiKey}).PullModel(ctx, model, func(progress PullProgress) {
+		if progress.Status != lastStatus {
+			fmt.Printf("Pull status: %s\n", progress.Status)
+			lastStatus = progress.Status
+		}
+	})
+}
 
-	baseURL := strings.TrimRight(ollamaBaseURL, "/")
+// PullModel pulls the specified model from the Ollama server
+func (c *Client) PullModel(ctx context.Context, model string, progressFn func(PullProgress)) error {
+	client := c.httpClient(0) // No timeout - we use context for cancellation
+
+	baseURL := c.GetOllamaBaseURL()
 	pullURL := fmt.Sprintf("%s/api/pull", baseURL)
 
 	requestBody := fmt.Sprintf(`{"name": "%s"}`, model)
@@ -184,8 +355,8 @@ func PullModel(ollamaBaseURL, apiKey, model string) error {
 		return fmt.Errorf("failed to create pull request: %w", err)
 	}
 
-	if apiKey != "" {
-		req.Header.Set("Authorization", "Bearer "+apiKey)
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
 	}
 	req.Header.Set("Content-Type", "application/json")
 
@@ -201,31 +372,93 @@ func PullModel(ollamaBaseURL, apiKey, model string) error {
 	}
 
 	// Stream and monitor pull progress
-	decoder := json.NewDecoder(resp.Body)
-	lastStatus := ""
+	return decodeProgress(ctx, resp.Body, "pull", progressFn)
+}
+
+// decodeProgress reads NDJSON progress events from body and reports each one to progressFn
+func decodeProgress(ctx context.Context, body io.Reader, operation string, progressFn func(PullProgress)) error {
+	decoder := json.NewDecoder(body)
 	for {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
 		default:
-			var data map[string]interface{}
-			if err := decoder.Decode(&data); err != nil {
+			var progress PullProgress
+			if err := decoder.Decode(&progress); err != nil {
 				if err == io.EOF {
 					return nil // Successful completion
 				}
-				return fmt.Errorf("error decoding pull response: %w", err)
+				return fmt.Errorf("error decoding %s response: %w", operation, err)
 			}
 
-			if status, ok := data["status"].(string); ok {
-				if status != lastStatus {
-					fmt.Printf("Pull status: %s\n", status)
-					lastStatus = status
-				}
+			if progress.Error != "" {
+				return fmt.Errorf("error during %s: %s", operation, progress.Error)
 			}
 
-			if errorMsg, ok := data["error"]; ok {
-				return fmt.Errorf("error during pull: %v", errorMsg)
+			if progress.Status != "" && progressFn != nil {
+				progressFn(progress)
 			}
 		}
 	}
 }
+
+// PushModel pushes the specified local model to the Ollama registry
+func (c *Client) PushModel(ctx context.Context, model string, progressFn func(PullProgress)) error {
+	client := c.httpClient(0) // No timeout - we use context for cancellation
+
+	baseURL := c.GetOllamaBaseURL()
+	pushURL := fmt.Sprintf("%s/api/push", baseURL)
+
+	requestBody := fmt.Sprintf(`{"model": "%s", "stream": true}`, model)
+	req, err := http.NewRequestWithContext(ctx, "POST", pushURL, strings.NewReader(requestBody))
+	if err != nil {
+		return fmt.Errorf("failed to create push request: %w", err)
+	}
+
+	if c.config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to Ollama API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+			return fmt.Errorf("authentication failed pushing '%s': make sure the Ollama server's public key is added to your ollama.com account and the model is named <namespace>/<model>: %s", model, string(body))
+		}
+		return fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	err = decodeProgress(ctx, resp.Body, "push", progressFn)
+	if err != nil && strings.Contains(strings.ToLower(err.Error()), "unauthorized") {
+		return fmt.Errorf("authentication failed pushing '%s': make sure the Ollama server's public key is added to your ollama.com account: %w", model, err)
+	}
+	return err
+}
+
+// DownloadModelfile retrieves the Modelfile of the specified model using Ollama API
+func (c *Client) DownloadModelfile(ctx context.Context, model string) (string, error) {
+	body, err := c.showModel(ctx, model)
+	if err != nil {
+		return "", err
+	}
+
+	var showResponse struct {
+		Modelfile string `json:"modelfile"`
+		Template  string `json:"template"`
+	}
+	if err := json.Unmarshal(body, &showResponse); err != nil {
+		return "", fmt.Errorf("failed to decode API response: %w", err)
+	}
+
+	// Older servers only expose the template
+	if showResponse.Modelfile == "" {
+		return showResponse.Template, nil
+	}
+	return showResponse.Modelfile, nil
+}
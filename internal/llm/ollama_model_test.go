@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newOllamaServer starts a server answering the Ollama /api/tags and /api/show
+// endpoints with the given bodies, calling check with every request
+func newOllamaServer(t *testing.T, tagsBody, showBody string, check func(*http.Request)) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(tagsBody))
+	})
+	mux.HandleFunc("POST /api/show", func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(showBody))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckModelExistsUserAgent(t *testing.T) {
+	const userAgent = "llm-go-test/1.0"
+	server := newOllamaServer(t, `{"models":[{"name":"llama3:latest","size":1024}]}`, `{}`, func(r *http.Request) {
+		if got := r.Header.Get("User-Agent"); got != userAgent {
+			t.Errorf("%s User-Agent = %q, want %q", r.URL.Path, got, userAgent)
+		}
+	})
+	client := NewClient(Config{BaseURL: server.URL + "/v1", Model: "llama3", UserAgent: userAgent})
+
+	exists, err := client.CheckModelExists("llama3")
+	if err != nil {
+		t.Fatalf("CheckModelExists() error = %v", err)
+	}
+	if !exists {
+		t.Error("CheckModelExists(\"llama3\") = false, want true for llama3:latest")
+	}
+}
+
+func TestGetOllamaModelInfoModifiedAt(t *testing.T) {
+	const tags = `{"models":[{"name":"qwen3:8b","size":5242880000,` +
+		`"modified_at":"2025-05-04T17:37:44.706015396-07:00"}]}`
+	const show = `{"details":{"family":"qwen3","parameter_size":"8.2B","quantization_level":"Q4_K_M"}}`
+	server := newOllamaServer(t, tags, show, func(*http.Request) {})
+	client := NewClient(Config{BaseURL: server.URL + "/v1", Model: "qwen3:8b"})
+
+	info, err := client.GetOllamaModelInfo("qwen3:8b")
+	if err != nil {
+		t.Fatalf("GetOllamaModelInfo() error = %v", err)
+	}
+	want := time.Date(2025, 5, 5, 0, 37, 44, 706015396, time.UTC)
+	if !info.ModifiedAt.Equal(want) {
+		t.Errorf("ModifiedAt = %v, want %v", info.ModifiedAt, want)
+	}
+	if info.Family != "qwen3" || info.ParameterSize != "8.2B" || info.Quantization != "Q4_K_M" {
+		t.Errorf("details = %q, %q, %q, want qwen3, 8.2B, Q4_K_M", info.Family, info.ParameterSize, info.Quantization)
+	}
+}
+
+func TestGetOllamaModelInfoWithoutModifiedAt(t *testing.T) {
+	server := newOllamaServer(t, `{"models":[{"name":"qwen3:8b","size":1024}]}`, `{}`, func(*http.Request) {})
+	client := NewClient(Config{BaseURL: server.URL + "/v1", Model: "qwen3:8b"})
+
+	info, err := client.GetOllamaModelInfo("qwen3:8b")
+	if err != nil {
+		t.Fatalf("GetOllamaModelInfo() error = %v", err)
+	}
+	if !info.ModifiedAt.IsZero() {
+		t.Errorf("ModifiedAt = %v, want the zero time", info.ModifiedAt)
+	}
+}
+
+func TestGetLoadedModelVRAMUsageDuringModelSwap(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"models":[{"name":"llama3:latest","model":"llama3:latest","size_vram":2048}]}`))
+	}))
+	t.Cleanup(server.Close)
+	client := NewClient(Config{BaseURL: server.URL + "/v1", Model: "llama3:latest"})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 10 {
+			client.SetModel("qwen3:8b")
+			client.SetModel("llama3:latest")
+		}
+	}()
+	for range 10 {
+		if _, err := client.GetLoadedModelVRAMUsage(t.Context()); err != nil && !errors.Is(err, ErrModelNotLoaded) {
+			t.Fatalf("GetLoadedModelVRAMUsage() error = %v", err)
+		}
+	}
+	<-done
+}
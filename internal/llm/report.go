@@ -0,0 +1,74 @@
+package llm
+
+import "time"
+
+// turnUsage is the token usage of a single interaction
+type turnUsage struct {
+	inputTokens  int
+	outputTokens int
+}
+
+// TokenSummary summarizes the tokens of the turns of a session
+type TokenSummary struct {
+	Min int     `json:"min" yaml:"min"`
+	Max int     `json:"max" yaml:"max"`
+	Avg float64 `json:"avg" yaml:"avg"`
+}
+
+// SessionReport summarizes the interactions of a session
+type SessionReport struct {
+	Turns        int          `json:"turns" yaml:"turns"`
+	InputTokens  TokenSummary `json:"input_tokens" yaml:"input_tokens"`
+	OutputTokens TokenSummary `json:"output_tokens" yaml:"output_tokens"`
+	// WallClockMs is the time since the client was created or its stats were reset
+	WallClockMs int64 `json:"wall_clock_ms" yaml:"wall_clock_ms"`
+	// ThinkingFraction is the share of the response time spent thinking
+	ThinkingFraction float64 `json:"thinking_fraction" yaml:"thinking_fraction"`
+	// EstimatedCostUSD is nil when no price is known for the model
+	EstimatedCostUSD *float64 `json:"estimated_cost_usd,omitempty" yaml:"estimated_cost_usd,omitempty"`
+}
+
+// GetSessionReport returns the summary of all interactions since the client was created
+// or its total stats were reset
+func (c *Client) GetSessionReport() SessionReport {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	report := SessionReport{
+		Turns:       len(c.turns),
+		WallClockMs: time.Since(c.sessionStart).Milliseconds(),
+	}
+	inputs := make([]int, len(c.turns))
+	outputs := make([]int, len(c.turns))
+	for i, turn := range c.turns {
+		inputs[i] = turn.inputTokens
+		outputs[i] = turn.outputTokens
+	}
+	report.InputTokens = summarizeTokens(inputs)
+	report.OutputTokens = summarizeTokens(outputs)
+
+	if total := c.totalThinkingDuration + c.totalResponseDuration; total > 0 {
+		report.ThinkingFraction = float64(c.totalThinkingDuration) / float64(total)
+	}
+	if price, ok := lookupModelPrice(c.config.Model); ok {
+		cost := (float64(c.totalInputTokens)*price.input + float64(c.totalOutputTokens)*price.output) / 1_000_000
+		report.EstimatedCostUSD = &cost
+	}
+	return report
+}
+
+// summarizeTokens returns the minimum, maximum and average of the token counts
+func summarizeTokens(tokens []int) TokenSummary {
+	if len(tokens) == 0 {
+		return TokenSummary{}
+	}
+	summary := TokenSummary{Min: tokens[0], Max: tokens[0]}
+	total := 0
+	for _, t := range tokens {
+		summary.Min = min(summary.Min, t)
+		summary.Max = max(summary.Max, t)
+		total += t
+	}
+	summary.Avg = float64(total) / float64(len(tokens))
+	return summary
+}
@@ -0,0 +1,39 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSessionReportSkipsFailedTurns(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 2 {
+			http.Error(w, `{"error":{"message":"context length exceeded"}}`, http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, chunkEvent("4"))
+		fmt.Fprint(w, usageEvent(10, 20))
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(server.Close)
+	client := newTestClient(server)
+
+	for i, wantErr := range []bool{false, true, false} {
+		if _, _, err := collectStream(t, client, false); (err != nil) != wantErr {
+			t.Fatalf("request %d: StreamResponse() error = %v, wantErr %v", i+1, err, wantErr)
+		}
+	}
+
+	report := client.GetSessionReport()
+	if report.Turns != 2 {
+		t.Errorf("Turns = %d, want 2 successful turns", report.Turns)
+	}
+	if report.InputTokens.Min != 10 || report.OutputTokens.Min != 20 {
+		t.Errorf("minimum tokens = %d in, %d out, want 10 in, 20 out", report.InputTokens.Min, report.OutputTokens.Min)
+	}
+}
@@ -0,0 +1,75 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// GetResponse sends a message with conversation history and returns the whole response
+// from a single non-streaming request. Token usage and timing are recorded like for
+// StreamResponse, with the whole request counted as response time.
+func (c *Client) GetResponse(messages []openai.ChatCompletionMessageParamUnion, hideThinking bool) (string, error) {
+	return c.GetResponseContext(context.Background(), messages, hideThinking)
+}
+
+// GetResponseContext is GetResponse with a context for cancellation
+func (c *Client) GetResponseContext(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, hideThinking bool) (string, error) {
+	ctx, cancel := c.withRequestTimeout(ctx)
+	defer cancel()
+
+	// Reset current interaction token counts and timing
+	c.mutex.Lock()
+	c.currentInputTokens = 0
+	c.currentOutputTokens = 0
+	c.currentCalls = 1
+	c.totalCalls++
+	c.usageTimeline = nil
+	c.startTime = time.Now()
+	c.thinkingStart = time.Time{}
+	c.thinkingDuration = 0
+	c.responseStart = time.Time{}
+	c.responseDuration = 0
+	c.firstTokenTime = time.Time{}
+	c.systemFingerprint = ""
+	c.mutex.Unlock()
+
+	completion, err := c.client.Chat.Completions.New(ctx, c.buildParams(messages))
+
+	c.mutex.Lock()
+	c.endTime = time.Now()
+	c.responseDuration = c.endTime.Sub(c.startTime)
+	c.totalResponseDuration += c.responseDuration
+	if err == nil {
+		c.currentInputTokens = int(completion.Usage.PromptTokens)
+		c.currentOutputTokens = int(completion.Usage.CompletionTokens)
+		c.totalInputTokens += c.currentInputTokens
+		c.totalOutputTokens += c.currentOutputTokens
+		c.systemFingerprint = completion.SystemFingerprint
+		c.updateAverages()
+	}
+	recordMetrics(c.currentInputTokens, c.currentOutputTokens, c.responseDuration, err)
+	c.mutex.Unlock()
+
+	if err = c.timeoutError(ctx, err); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return "", err
+		}
+		return "", fmt.Errorf("error getting response: %w", err)
+	}
+	if len(completion.Choices) == 0 {
+		return "", errors.New("error getting response: no choices returned")
+	}
+
+	response := completion.Choices[0].Message.Content
+	if hideThinking {
+		response = c.removeThinkingBlocks(response)
+	}
+	c.mutex.Lock()
+	c.lastResponse = response
+	c.mutex.Unlock()
+	return response, nil
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// Default retry settings for transient API errors
+const (
+	defaultRetryAttempts  = 3
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 30 * time.Second
+)
+
+// RetryConfig controls how StreamResponse retries requests failing with a rate limit (429)
+// or server (5xx) error before any content was received
+type RetryConfig struct {
+	// MaxAttempts is the total number of attempts, including the first (default 3)
+	MaxAttempts int
+	// BaseDelay is the delay before the first retry, doubled for each further retry (default 1s)
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between attempts (default 30s)
+	MaxDelay time.Duration
+	// Jitter is the fraction of each delay that is randomized, from 0 to 1 (default 0)
+	Jitter float64
+}
+
+// withDefaults returns the retry configuration with unset values replaced by the defaults
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.MaxAttempts <= 0 {
+		r.MaxAttempts = defaultRetryAttempts
+	}
+	if r.BaseDelay <= 0 {
+		r.BaseDelay = defaultRetryBaseDelay
+	}
+	if r.MaxDelay <= 0 {
+		r.MaxDelay = defaultRetryMaxDelay
+	}
+	r.Jitter = min(max(r.Jitter, 0), 1)
+	return r
+}
+
+// delay returns the time to wait after the given failed attempt, starting at 1
+func (r RetryConfig) delay(attempt int) time.Duration {
+	delay := r.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+	if r.Jitter > 0 {
+		spread := float64(delay) * r.Jitter
+		delay = time.Duration(float64(delay) - spread + rand.Float64()*2*spread)
+	}
+	return delay
+}
+
+// isRetryable reports whether err is a rate limit or server error worth retrying
+func isRetryable(err error) bool {
+	var apiErr *openai.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// streamWithRetry runs streamAttempt, retrying with exponential backoff while it fails
+// with a retryable error before any chunk was received
+func (c *Client) streamWithRetry(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, hideThinking bool, chunkChan chan<- string, state *streamState) error {
+	retry := c.config.Retry.withDefaults()
+	startChunk := state.chunkIndex
+	for attempt := 1; ; attempt++ {
+		err := c.streamAttempt(ctx, messages, hideThinking, chunkChan, state)
+		if err == nil || attempt >= retry.MaxAttempts || state.chunkIndex != startChunk || !isRetryable(err) {
+			return err
+		}
+
+		delay := retry.delay(attempt)
+		if c.config.Verbose {
+			fmt.Fprintf(os.Stderr, "Request failed: %v\nRetrying in %v (attempt %d/%d)\n", err, delay.Round(time.Millisecond), attempt+1, retry.MaxAttempts)
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("retry cancelled: %w", ctx.Err())
+		case <-time.After(delay):
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sseDone is the data sent by OpenAI-compatible servers to end a stream
+const sseDone = "[DONE]"
+
+// ParseSSEChunk parses a single line of a server-sent events stream, for consumers
+// reading streams without the SDK. It returns the payload of "data:" lines and reports
+// the [DONE] marker with isDone. Blank lines, comments and the event, id and retry
+// fields yield no data.
+func ParseSSEChunk(line string) (data string, isDone bool, err error) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" || strings.HasPrefix(line, ":") {
+		return "", false, nil
+	}
+
+	field, value, _ := strings.Cut(line, ":")
+	// A single space after the colon is part of the syntax, not of the value
+	value = strings.TrimPrefix(value, " ")
+	switch field {
+	case "data":
+		if strings.TrimSpace(value) == sseDone {
+			return "", true, nil
+		}
+		return value, false, nil
+	case "event", "id", "retry":
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("%w: unexpected SSE line %q", ErrMalformedStream, line)
+	}
+}
@@ -0,0 +1,43 @@
+package llm
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseSSEChunk(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		wantData string
+		wantDone bool
+		wantErr  error
+	}{
+		{"data with space", `data: {"id":"1"}`, `{"id":"1"}`, false, nil},
+		{"data without space", `data:{"id":"1"}`, `{"id":"1"}`, false, nil},
+		{"data keeps further spaces", "data:  indented\r\n", " indented", false, nil},
+		{"done", "data: [DONE]", "", true, nil},
+		{"done without space", "data:[DONE]\n", "", true, nil},
+		{"comment", ": keep-alive", "", false, nil},
+		{"event", "event: message", "", false, nil},
+		{"id", "id: 42", "", false, nil},
+		{"retry", "retry: 3000", "", false, nil},
+		{"blank line", "\r\n", "", false, nil},
+		{"unknown field", "foo: bar", "", false, ErrMalformedStream},
+		{"no colon", "garbage", "", false, ErrMalformedStream},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, isDone, err := ParseSSEChunk(tt.line)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if data != tt.wantData {
+				t.Errorf("data = %q, want %q", data, tt.wantData)
+			}
+			if isDone != tt.wantDone {
+				t.Errorf("isDone = %v, want %v", isDone, tt.wantDone)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"github.com/openai/openai-go/packages/ssestream"
+)
+
+// maxMalformedChunks is the number of consecutive malformed chunks that aborts a stream
+const maxMalformedChunks = 3
+
+// ErrMalformedStream is returned when too many consecutive stream chunks cannot be parsed
+var ErrMalformedStream = errors.New("malformed stream")
+
+// tolerantDecoder skips SSE events whose data is not valid JSON instead of failing the
+// whole stream, as backends may emit broken chunks while crashing or restarting
+type tolerantDecoder struct {
+	ssestream.Decoder
+	malformed int
+	err       error
+}
+
+// Next advances to the next event with valid JSON data
+func (d *tolerantDecoder) Next() bool {
+	for d.Decoder.Next() {
+		data := d.Decoder.Event().Data
+		// Keep-alive comments such as ": ping" are dispatched as events without data
+		if len(data) == 0 {
+			continue
+		}
+		if json.Valid(data) || bytes.HasPrefix(data, []byte(sseDone)) {
+			d.malformed = 0
+			return true
+		}
+
+		d.malformed++
+		slog.Warn("skipping malformed stream chunk", "data", string(data), "consecutive", d.malformed)
+		if d.malformed >= maxMalformedChunks {
+			d.err = fmt.Errorf("%w: %d consecutive chunks could not be parsed", ErrMalformedStream, d.malformed)
+			return false
+		}
+	}
+	return false
+}
+
+// Err returns the decoding error, including too many malformed chunks
+func (d *tolerantDecoder) Err() error {
+	if d.err != nil {
+		return d.err
+	}
+	return d.Decoder.Err()
+}
+
+// newChatStream starts a streaming chat completion that tolerates malformed chunks
+func (c *Client) newChatStream(ctx context.Context, params openai.ChatCompletionNewParams) *ssestream.Stream[openai.ChatCompletionChunk] {
+	var raw *http.Response
+	// Retries are handled by streamWithRetry
+	err := c.client.Post(ctx, "chat/completions", params, &raw, option.WithJSONSet("stream", true), option.WithMaxRetries(0))
+
+	var decoder ssestream.Decoder
+	if d := ssestream.NewDecoder(raw); d != nil {
+		decoder = &tolerantDecoder{Decoder: d}
+	}
+	return ssestream.NewStream[openai.ChatCompletionChunk](decoder, err)
+}
+
+// nextChunk advances the stream, turning a panic in the SDK decoder into an error
+func nextChunk(stream *ssestream.Stream[openai.ChatCompletionChunk]) (ok bool, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			ok = false
+			err = fmt.Errorf("%w: panic while decoding chunk: %v", ErrMalformedStream, r)
+		}
+	}()
+	return stream.Next(), nil
+}
@@ -0,0 +1,191 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+// collectStream streams a response with client and returns the returned response, the
+// content received through the chunk channel and the error
+func collectStream(t *testing.T, client *Client, hideThinking bool) (response, streamed string, err error) {
+	t.Helper()
+	chunkChan := make(chan string)
+	done := make(chan string)
+	go func() {
+		var b strings.Builder
+		for chunk := range chunkChan {
+			b.WriteString(chunk)
+		}
+		done <- b.String()
+	}()
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Hello")}
+	response, err = client.StreamResponse(messages, hideThinking, chunkChan)
+	return response, <-done, err
+}
+
+func TestStreamResponseMalformedChunks(t *testing.T) {
+	tests := []struct {
+		name    string
+		events  []string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "malformed chunk between valid ones",
+			events: []string{
+				chunkEvent("Hello"),
+				"data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"delta\n\n",
+				chunkEvent(", world"),
+			},
+			want: "Hello, world",
+		},
+		{
+			name: "keep-alive comments",
+			events: []string{
+				": ping\n\n",
+				chunkEvent("Hello"),
+				": PROCESSING\n\n",
+				": PROCESSING\n\n",
+				": PROCESSING\n\n",
+				": PROCESSING\n\n",
+				chunkEvent(", world"),
+				usageEvent(5, 2),
+			},
+			want: "Hello, world",
+		},
+		{
+			name: "too many consecutive malformed chunks",
+			events: []string{
+				chunkEvent("Hello"),
+				"data: {\n\n",
+				"data: {\n\n",
+				"data: {\n\n",
+				chunkEvent(", world"),
+			},
+			wantErr: ErrMalformedStream,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(newSSEServer(t, tt.events))
+			response, _, err := collectStream(t, client, false)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("StreamResponse() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("StreamResponse() error = %v", err)
+			}
+			if response != tt.want {
+				t.Errorf("StreamResponse() = %q, want %q", response, tt.want)
+			}
+		})
+	}
+}
+
+func TestStreamResponseSplitThinkingTags(t *testing.T) {
+	events := []string{
+		chunkEvent("<reas"),
+		chunkEvent("oning>Let me"),
+		chunkEvent(" think.</"),
+		chunkEvent("reasoning"),
+		chunkEvent(">\nThe answer is 4 <"),
+		chunkEvent(" 5."),
+	}
+	server := newSSEServer(t, events)
+
+	tests := []struct {
+		name         string
+		hideThinking bool
+		want         string
+	}{
+		{"shown", false, "<reasoning>Let me think.</reasoning>\nThe answer is 4 < 5."},
+		{"hidden", true, "\nThe answer is 4 < 5."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClient(Config{
+				APIKey:        "test",
+				BaseURL:       server.URL,
+				Model:         "test",
+				ThinkStartTag: "<reasoning>",
+				ThinkEndTag:   "</reasoning>",
+			})
+			response, streamed, err := collectStream(t, client, tt.hideThinking)
+			if err != nil {
+				t.Fatalf("StreamResponse() error = %v", err)
+			}
+			if response != tt.want {
+				t.Errorf("StreamResponse() = %q, want %q", response, tt.want)
+			}
+			if streamed != tt.want {
+				t.Errorf("streamed chunks = %q, want %q", streamed, tt.want)
+			}
+			if client.GetStats().ThinkingTime <= 0 {
+				t.Error("ThinkingTime = 0, want the time between the split tags")
+			}
+		})
+	}
+}
+
+// newDroppingServer starts a server streaming chunks and closing the connection after
+// the first n of them, without the [DONE] marker
+func newDroppingServer(t *testing.T, chunks []string, n int) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks[:n] {
+			fmt.Fprint(w, chunkEvent(chunk))
+		}
+		w.(http.Flusher).Flush()
+		// Abort the chunked response so the client sees a truncated body
+		panic(http.ErrAbortHandler)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStreamResponseConnectionDropped(t *testing.T) {
+	chunks := []string{"The answer", " is", " 4", "."}
+	client := newTestClient(newDroppingServer(t, chunks, 2))
+
+	response, streamed, err := collectStream(t, client, false)
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("StreamResponse() error = %v, want %v", err, io.ErrUnexpectedEOF)
+	}
+	if response != "" {
+		t.Errorf("StreamResponse() = %q, want no response on error", response)
+	}
+	if want := "The answer is"; streamed != want {
+		t.Errorf("streamed chunks = %q, want the partial response %q", streamed, want)
+	}
+}
+
+func TestStreamResponseInStreamError(t *testing.T) {
+	events := []string{
+		chunkEvent("The answer"),
+		`data: {"error": {"message": "context length exceeded"}}` + "\n\n",
+	}
+	client := newTestClient(newSSEServer(t, events))
+
+	_, streamed, err := collectStream(t, client, false)
+	if !errors.Is(err, ErrInStreamError) {
+		t.Fatalf("StreamResponse() error = %v, want %v", err, ErrInStreamError)
+	}
+	if !strings.Contains(err.Error(), "context length exceeded") {
+		t.Errorf("StreamResponse() error = %v, want the backend message", err)
+	}
+	if streamed != "The answer" {
+		t.Errorf("streamed chunks = %q, want the partial response %q", streamed, "The answer")
+	}
+}
@@ -0,0 +1,117 @@
+package llm
+
+import "strings"
+
+// removeThinkingBlocks returns the response content without its complete thinking
+// blocks, or the response unchanged if it has none
+func (c *Client) removeThinkingBlocks(s string) string {
+	thinking, content := SplitThinkingBlocks(s, c.config.ThinkStartTag, c.config.ThinkEndTag)
+	if len(thinking) == 0 {
+		return s
+	}
+	return content
+}
+
+// SplitThinking returns the content of the complete thinking blocks delimited by
+// startTag and endTag, without the tags and separated by blank lines, and the response
+// text around them. The thinking is empty when s has no complete thinking block.
+func SplitThinking(s, startTag, endTag string) (thinking, content string) {
+	blocks, content := SplitThinkingBlocks(s, startTag, endTag)
+	if len(blocks) == 0 {
+		return "", s
+	}
+	return strings.Join(blocks, "\n\n"), content
+}
+
+// SplitThinkingBlocks returns the trimmed content of each complete thinking block
+// delimited by startTag and endTag, without the tags, and the trimmed text outside of
+// them. An unterminated block is left in the text.
+func SplitThinkingBlocks(s, startTag, endTag string) (blocks []string, content string) {
+	var outside strings.Builder
+	for {
+		startIdx := strings.Index(s, startTag)
+		if startIdx == -1 {
+			break
+		}
+		endIdx := strings.Index(s[startIdx+len(startTag):], endTag)
+		if endIdx == -1 {
+			break
+		}
+		endIdx += startIdx + len(startTag)
+		blocks = append(blocks, strings.TrimSpace(s[startIdx+len(startTag):endIdx]))
+		outside.WriteString(s[:startIdx])
+		s = s[endIdx+len(endTag):]
+	}
+	outside.WriteString(s)
+	return blocks, strings.TrimSpace(outside.String())
+}
+
+// thinkingPiece is a part of a streamed response, either a whole thinking tag or text
+// inside or outside of a thinking block
+type thinkingPiece struct {
+	text string
+	// thinking is set for thinking block content and tags
+	thinking   bool
+	isStartTag bool
+	isEndTag   bool
+}
+
+// thinkingScanner finds thinking blocks in a streamed response whose tags may be split
+// over several chunks, holding back the end of a chunk as long as it may start a tag
+type thinkingScanner struct {
+	startTag   string
+	endTag     string
+	inThinking bool
+	pending    string
+}
+
+// scan returns the pieces of chunk that are known not to be part of an incomplete tag,
+// with each tag as a piece of its own
+func (s *thinkingScanner) scan(chunk string) []thinkingPiece {
+	var pieces []thinkingPiece
+	text := s.pending + chunk
+	for {
+		tag := s.startTag
+		if s.inThinking {
+			tag = s.endTag
+		}
+		idx := strings.Index(text, tag)
+		if idx == -1 {
+			// Hold back the longest suffix that may be the start of the tag
+			keep := partialTagLength(text, tag)
+			if keep < len(text) {
+				pieces = append(pieces, thinkingPiece{text: text[:len(text)-keep], thinking: s.inThinking})
+			}
+			s.pending = text[len(text)-keep:]
+			return pieces
+		}
+		if idx > 0 {
+			pieces = append(pieces, thinkingPiece{text: text[:idx], thinking: s.inThinking})
+		}
+		pieces = append(pieces, thinkingPiece{text: tag, thinking: true, isStartTag: !s.inThinking, isEndTag: s.inThinking})
+		s.inThinking = !s.inThinking
+		text = text[idx+len(tag):]
+	}
+}
+
+// flush returns the text held back at the end of the response, which turned out not to
+// be a tag
+func (s *thinkingScanner) flush() []thinkingPiece {
+	if s.pending == "" {
+		return nil
+	}
+	piece := thinkingPiece{text: s.pending, thinking: s.inThinking}
+	s.pending = ""
+	return []thinkingPiece{piece}
+}
+
+// partialTagLength returns the length of the longest suffix of text that is a proper
+// prefix of tag
+func partialTagLength(text, tag string) int {
+	for n := min(len(tag)-1, len(text)); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
@@ -0,0 +1,31 @@
+package llm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitThinkingBlocks(t *testing.T) {
+	tests := []struct {
+		name        string
+		s           string
+		wantBlocks  []string
+		wantContent string
+	}{
+		{"no thinking", "The answer is 4.", nil, "The answer is 4."},
+		{"one block", "<think>\nLet me add.\n</think>\n\nThe answer is 4.", []string{"Let me add."}, "The answer is 4."},
+		{"several blocks", "<think>a</think>First. <think>b</think>Second.", []string{"a", "b"}, "First. Second."},
+		{"unterminated block", "<think>still thinking", nil, "<think>still thinking"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks, content := SplitThinkingBlocks(tt.s, DefaultThinkStartTag, DefaultThinkEndTag)
+			if !reflect.DeepEqual(blocks, tt.wantBlocks) {
+				t.Errorf("blocks = %q, want %q", blocks, tt.wantBlocks)
+			}
+			if content != tt.wantContent {
+				t.Errorf("content = %q, want %q", content, tt.wantContent)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/packages/param"
+)
+
+// defaultMaxToolRounds is used when MaxToolRounds is not set
+const defaultMaxToolRounds = 10
+
+// ErrMaxToolRounds is returned when the model keeps requesting tools past MaxToolRounds
+var ErrMaxToolRounds = errors.New("maximum tool rounds exceeded")
+
+// ToolDispatcher executes the named tool with the JSON arguments chosen by the model.
+// It may be called concurrently when the model requests several tools at once.
+type ToolDispatcher func(name string, args json.RawMessage) (string, error)
+
+// ChatWithTools sends the conversation with the given tools and runs every tool call the
+// model requests through dispatcher, feeding the results back until the model returns
+// a final text response
+func (c *Client) ChatWithTools(ctx context.Context, messages []openai.ChatCompletionMessageParamUnion, tools []openai.ChatCompletionToolParam, dispatcher ToolDispatcher) (response string, err error) {
+	// Reset current interaction token counts and timing
+	c.mutex.Lock()
+	c.currentInputTokens = 0
+	c.currentOutputTokens = 0
+	c.currentCalls = 0
+	c.startTime = time.Now()
+	c.thinkingDuration = 0
+	c.responseDuration = 0
+	c.mutex.Unlock()
+	defer func() { c.recordResponseTime(err == nil) }()
+
+	maxRounds := c.config.MaxToolRounds
+	if maxRounds <= 0 {
+		maxRounds = defaultMaxToolRounds
+	}
+
+	conversation := append([]openai.ChatCompletionMessageParamUnion(nil), messages...)
+	for round := 0; round <= maxRounds; round++ {
+		params := c.buildParams(conversation)
+		params.Tools = tools
+		if c.config.ParallelToolCalls != nil && len(tools) > 0 {
+			params.ParallelToolCalls = param.NewOpt(*c.config.ParallelToolCalls)
+		}
+
+		completion, err := c.client.Chat.Completions.New(ctx, params)
+		c.recordCompletion(completion)
+		if err != nil {
+			return "", fmt.Errorf("error during tool chat: %w", err)
+		}
+		if len(completion.Choices) == 0 {
+			return "", fmt.Errorf("error during tool chat: no choices returned")
+		}
+
+		message := completion.Choices[0].Message
+		if len(message.ToolCalls) == 0 {
+			return message.Content, nil
+		}
+		// Do not run tools whose results could not be sent back anymore
+		if round == maxRounds {
+			break
+		}
+
+		results, err := dispatchToolCalls(message.ToolCalls, dispatcher)
+		if err != nil {
+			return "", err
+		}
+		conversation = append(conversation, message.ToParam())
+		conversation = append(conversation, results...)
+	}
+	return "", fmt.Errorf("%w (%d)", ErrMaxToolRounds, maxRounds)
+}
+
+// dispatchToolCalls runs the tool calls of a response concurrently and returns the
+// tool result messages in the order of the calls
+func dispatchToolCalls(calls []openai.ChatCompletionMessageToolCall, dispatcher ToolDispatcher) ([]openai.ChatCompletionMessageParamUnion, error) {
+	results := make([]string, len(calls))
+	errs := make([]error, len(calls))
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = dispatcher(call.Function.Name, json.RawMessage(call.Function.Arguments))
+		}()
+	}
+	wg.Wait()
+
+	messages := make([]openai.ChatCompletionMessageParamUnion, 0, len(calls))
+	for i, call := range calls {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("tool %s failed: %w", call.Function.Name, errs[i])
+		}
+		messages = append(messages, openai.ToolMessage(results[i], call.ID))
+	}
+	return messages, nil
+}
+
+// recordCompletion adds the call and token usage of a non-streaming completion to the stats
+func (c *Client) recordCompletion(completion *openai.ChatCompletion) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.currentCalls++
+	c.totalCalls++
+	if completion == nil {
+		return
+	}
+	c.currentInputTokens += int(completion.Usage.PromptTokens)
+	c.currentOutputTokens += int(completion.Usage.CompletionTokens)
+	c.totalInputTokens += int(completion.Usage.PromptTokens)
+	c.totalOutputTokens += int(completion.Usage.CompletionTokens)
+}
+
+// recordResponseTime records the final timing of a non-streaming interaction, and adds
+// it to the session averages when it succeeded
+func (c *Client) recordResponseTime(succeeded bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.endTime = time.Now()
+	c.responseDuration = c.endTime.Sub(c.startTime)
+	c.totalResponseDuration += c.responseDuration
+	if succeeded {
+		c.updateAverages()
+	}
+}
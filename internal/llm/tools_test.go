@@ -0,0 +1,50 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/openai/openai-go"
+)
+
+// toolCallCompletion is a chat completion always asking for the same tool call
+const toolCallCompletion = `{"id":"chatcmpl-1","object":"chat.completion","created":0,"model":"test",` +
+	`"choices":[{"index":0,"finish_reason":"tool_calls","message":{"role":"assistant","content":null,` +
+	`"tool_calls":[{"id":"call_%d","type":"function","function":{"name":"lookup","arguments":"{}"}}]}}],` +
+	`"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`
+
+func TestChatWithToolsMaxRounds(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := requests.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, toolCallCompletion, n)
+	}))
+	t.Cleanup(server.Close)
+
+	const maxRounds = 3
+	client := NewClient(Config{APIKey: "test", BaseURL: server.URL, Model: "test", MaxToolRounds: maxRounds})
+	var dispatched atomic.Int32
+	dispatcher := func(name string, args json.RawMessage) (string, error) {
+		dispatched.Add(1)
+		return "result", nil
+	}
+	tools := []openai.ChatCompletionToolParam{{Function: openai.FunctionDefinitionParam{Name: "lookup"}}}
+	messages := []openai.ChatCompletionMessageParamUnion{openai.UserMessage("Hello")}
+
+	_, err := client.ChatWithTools(t.Context(), messages, tools, dispatcher)
+	if !errors.Is(err, ErrMaxToolRounds) {
+		t.Fatalf("ChatWithTools() error = %v, want %v", err, ErrMaxToolRounds)
+	}
+	if got := requests.Load(); got != maxRounds+1 {
+		t.Errorf("requests = %d, want %d", got, maxRounds+1)
+	}
+	if got := dispatched.Load(); got != maxRounds {
+		t.Errorf("dispatched tool calls = %d, want %d", got, maxRounds)
+	}
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"fmt"
+	"net/http"
+	"runtime"
+)
+
+// Version is the llm-go version reported in the User-Agent header, set at build time with
+// -ldflags "-X llm-go/internal/llm.Version=<version>"
+var Version = "dev"
+
+// DefaultUserAgent returns the User-Agent sent when Config.UserAgent is empty
+func DefaultUserAgent() string {
+	return fmt.Sprintf("llm-go/%s (%s/%s; %s)", Version, runtime.GOOS, runtime.GOARCH, runtime.Version())
+}
+
+// userAgentTransport sets the User-Agent header on every request
+type userAgentTransport struct {
+	base      http.RoundTripper
+	userAgent string
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+	return t.base.RoundTrip(req)
+}
+
+// withUserAgent returns a copy of client whose requests carry the given User-Agent
+func withUserAgent(client *http.Client, userAgent string) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	wrapped := *client
+	wrapped.Transport = &userAgentTransport{base: base, userAgent: userAgent}
+	return &wrapped
+}
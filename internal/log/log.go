@@ -0,0 +1,72 @@
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrSessionNotFound is returned when the audit log has no entries for a conversation
+var ErrSessionNotFound = errors.New("conversation not found in log")
+
+// LogEntry is a single message recorded in the JSONL audit log
+type LogEntry struct {
+	ConversationID string    `json:"conversation_id"`
+	Time           time.Time `json:"time"`
+	Role           string    `json:"role"`
+	Content        string    `json:"content"`
+}
+
+// Append writes the entries to the audit log at path, one JSON object per line
+func Append(path string, entries ...LogEntry) error {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	encoder := json.NewEncoder(file)
+	for _, entry := range entries {
+		if err := encoder.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write log entry: %w", err)
+		}
+	}
+	return nil
+}
+
+// FindSession returns the entries of the conversation with the given ID, in log order
+func FindSession(logPath, sessionID string) ([]LogEntry, error) {
+	file, err := os.Open(logPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	defer file.Close()
+
+	var entries []LogEntry
+	scanner := bufio.NewScanner(file)
+	// Responses can be much longer than the default 64KB line limit
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("invalid log entry at %s:%d: %w", logPath, line, err)
+		}
+		if entry.ConversationID == sessionID {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrSessionNotFound, sessionID)
+	}
+	return entries, nil
+}
@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"llm-go/internal/llm"
+
+	"github.com/openai/openai-go"
+)
+
+// openAIExport is the {"messages": [...]} conversation format used by OpenAI-compatible tools
+type openAIExport struct {
+	Messages []openAIExportMessage `json:"messages"`
+}
+
+// openAIExportMessage is a single message of an OpenAI export
+type openAIExportMessage struct {
+	Role       string          `json:"role"`
+	Content    json.RawMessage `json:"content"`
+	ToolCallID string          `json:"tool_call_id,omitempty"`
+}
+
+// LoadFromOpenAIExport creates a Memory from a {"messages": [{"role": ..., "content": ...}]}
+// conversation exported by OpenAI-compatible tools
+func LoadFromOpenAIExport(data []byte) (*Memory, error) {
+	var export openAIExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		return nil, fmt.Errorf("failed to parse conversation export: %w", err)
+	}
+
+	m := NewMemory()
+	for i, exported := range export.Messages {
+		content, err := exportedContent(exported.Content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid content in message %d: %w", i, err)
+		}
+
+		switch exported.Role {
+		case llm.SystemRoleSystem:
+			m.AddMessage(openai.SystemMessage(content))
+		case llm.SystemRoleDeveloper:
+			m.SetSystemRole(llm.SystemRoleDeveloper)
+			m.AddMessage(openai.DeveloperMessage(content))
+		case "user":
+			m.AddUserMessage(content)
+		case "assistant":
+			m.AddAssistantMessage(content)
+		case "tool":
+			m.AddMessage(openai.ToolMessage(content, exported.ToolCallID))
+		default:
+			return nil, fmt.Errorf("unsupported role '%s' in message %d", exported.Role, i)
+		}
+	}
+	return m, nil
+}
+
+// exportedContent returns the text of a string or content parts array
+func exportedContent(raw json.RawMessage) (string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return "", nil
+	}
+
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+
+	var parts []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &parts); err != nil {
+		return "", fmt.Errorf("content must be a string or an array of parts: %w", err)
+	}
+	var b strings.Builder
+	for _, part := range parts {
+		if part.Type == "text" {
+			b.WriteString(part.Text)
+		}
+	}
+	return b.String(), nil
+}
+
+// SaveAsOpenAIExport encodes the conversation in the {"messages": [...]} format read by
+// OpenAI-compatible tools
+func SaveAsOpenAIExport(m *Memory) ([]byte, error) {
+	export := openAIExport{Messages: make([]openAIExportMessage, 0, m.Len())}
+	for _, message := range m.GetMessages() {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export message: %w", err)
+		}
+		content, err := json.Marshal(text)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export message: %w", err)
+		}
+
+		exported := openAIExportMessage{
+			Role:    llm.MessageRole(message),
+			Content: content,
+		}
+		if message.OfTool != nil {
+			exported.ToolCallID = message.OfTool.ToolCallID
+		}
+		export.Messages = append(export.Messages, exported)
+	}
+
+	data, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode conversation export: %w", err)
+	}
+	return data, nil
+}
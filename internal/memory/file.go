@@ -0,0 +1,139 @@
+package memory
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// sessionFile is the format of SaveToFile: the conversation start and every message
+// with the time it was added
+type sessionFile struct {
+	CreatedAt time.Time      `json:"created_at"`
+	Messages  []sessionEntry `json:"messages"`
+}
+
+// sessionEntry is a chat message with "time" and, for responses that had thinking
+// blocks, "thinking" fields added to its JSON object
+type sessionEntry struct {
+	Message  openai.ChatCompletionMessageParamUnion
+	Time     time.Time
+	Thinking string
+}
+
+// MarshalJSON writes the message fields, the time and the thinking as a single object
+func (e sessionEntry) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(e.Message)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields["time"], err = json.Marshal(e.Time); err != nil {
+		return nil, err
+	}
+	if e.Thinking != "" {
+		if fields["thinking"], err = json.Marshal(e.Thinking); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
+
+// UnmarshalJSON reads the time and thinking, if any, and decodes the other fields as the message
+func (e *sessionEntry) UnmarshalJSON(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	if raw, ok := fields["time"]; ok {
+		if err := json.Unmarshal(raw, &e.Time); err != nil {
+			return err
+		}
+		delete(fields, "time")
+	}
+	if raw, ok := fields["thinking"]; ok {
+		if err := json.Unmarshal(raw, &e.Thinking); err != nil {
+			return err
+		}
+		delete(fields, "thinking")
+	}
+	message, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(message, &e.Message)
+}
+
+// SaveToFile writes the conversation history to path as a JSON object holding the
+// conversation start time and the messages with the time each was added
+func (m *Memory) SaveToFile(path string) error {
+	session := sessionFile{Messages: make([]sessionEntry, len(m.messages))}
+	for i, message := range m.messages {
+		session.Messages[i] = sessionEntry{Message: message, Time: m.meta[i].addedAt, Thinking: m.meta[i].thinking}
+	}
+	if len(m.meta) > 0 {
+		session.CreatedAt = m.createdAt()
+	}
+	data, err := json.MarshalIndent(session, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write conversation file: %w", err)
+	}
+	return nil
+}
+
+// LoadFromFile replaces the conversation history with the messages saved by SaveToFile,
+// restoring their timestamps. Files holding a bare array of messages, as written by
+// earlier versions, are accepted with every message timestamped now.
+// System messages are converted to the configured system role, and the oldest turns
+// are dropped when the file holds more than the sliding window.
+func (m *Memory) LoadFromFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read conversation file: %w", err)
+	}
+	var session sessionFile
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		var messages []openai.ChatCompletionMessageParamUnion
+		if err := json.Unmarshal(data, &messages); err != nil {
+			return fmt.Errorf("failed to parse conversation file %s: %w", path, err)
+		}
+		for _, message := range messages {
+			session.Messages = append(session.Messages, sessionEntry{Message: message})
+		}
+	} else if err := json.Unmarshal(data, &session); err != nil {
+		return fmt.Errorf("failed to parse conversation file %s: %w", path, err)
+	}
+
+	m.Clear()
+	m.messages = make([]openai.ChatCompletionMessageParamUnion, len(session.Messages))
+	m.meta = make([]messageMeta, len(session.Messages))
+	now := time.Now()
+	for i, entry := range session.Messages {
+		m.messages[i] = entry.Message
+		m.meta[i].thinking = entry.Thinking
+		// Messages without a time fall back to the conversation start
+		switch {
+		case !entry.Time.IsZero():
+			m.meta[i].addedAt = entry.Time
+		case !session.CreatedAt.IsZero():
+			m.meta[i].addedAt = session.CreatedAt
+		default:
+			m.meta[i].addedAt = now
+		}
+	}
+	m.SetSystemRole(m.systemRole)
+	for m.windowSize > 0 && m.countTurns() > m.windowSize {
+		m.evictOldestTurn()
+	}
+	return nil
+}
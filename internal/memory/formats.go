@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+
+	"llm-go/internal/llm"
+)
+
+// ExportFunc writes the conversation held by m to w in a specific format
+type ExportFunc func(m *Memory, w io.Writer) error
+
+var (
+	exportFormatsMutex sync.RWMutex
+	exportFormats      = map[string]ExportFunc{
+		"json":     exportJSON,
+		"markdown": exportMarkdown,
+		"html":     exportHTML,
+		"text":     exportText,
+	}
+)
+
+// RegisterExportFormat adds a format to Export, replacing any format with the same name
+func RegisterExportFormat(name string, fn ExportFunc) {
+	exportFormatsMutex.Lock()
+	defer exportFormatsMutex.Unlock()
+	exportFormats[name] = fn
+}
+
+// ExportFormats returns the names of the formats supported by Export, sorted
+func ExportFormats() []string {
+	exportFormatsMutex.RLock()
+	defer exportFormatsMutex.RUnlock()
+	names := make([]string, 0, len(exportFormats))
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Export writes the conversation to w in the given format: "json", "markdown", "html",
+// "text" or one added with RegisterExportFormat
+func (m *Memory) Export(format string, w io.Writer) error {
+	exportFormatsMutex.RLock()
+	fn, ok := exportFormats[format]
+	exportFormatsMutex.RUnlock()
+	if !ok {
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	return fn(m, w)
+}
+
+// exportJSON writes the conversation in the OpenAI {"messages": [...]} format
+func exportJSON(m *Memory, w io.Writer) error {
+	data, err := SaveAsOpenAIExport(m)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%s\n", data); err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	return nil
+}
+
+// exportText writes the conversation as plain text, one "[role]: text" entry per message
+func exportText(m *Memory, w io.Writer) error {
+	return m.PrettyPrint(w, false)
+}
+
+// exportMarkdown writes the conversation in the format of ExportMarkdown
+func exportMarkdown(m *Memory, w io.Writer) error {
+	return m.ExportMarkdown(w)
+}
+
+// ExportMarkdown writes the conversation as Markdown with a bold role heading per message
+// and a horizontal rule between messages. The thinking kept with a response, or thinking
+// blocks still in its text, is wrapped in a collapsible <details> block.
+func (m *Memory) ExportMarkdown(w io.Writer) error {
+	for i, message := range m.messages {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			text = fmt.Sprintf("<%v>", err)
+		}
+
+		var b strings.Builder
+		if i > 0 {
+			b.WriteString("---\n\n")
+		}
+		fmt.Fprintf(&b, "**%s:**\n\n", roleTitle(llm.MessageRole(message)))
+		thinking := m.meta[i].thinking
+		if thinking == "" {
+			thinking, text = llm.SplitThinking(text, m.thinkStartTag, m.thinkEndTag)
+		}
+		if thinking != "" {
+			fmt.Fprintf(&b, "<details>\n<summary>Thinking</summary>\n\n%s\n\n</details>\n\n", thinking)
+		}
+		fmt.Fprintf(&b, "%s\n\n", text)
+		if _, err := io.WriteString(w, b.String()); err != nil {
+			return fmt.Errorf("failed to write conversation: %w", err)
+		}
+	}
+	return nil
+}
+
+// roleTitle capitalizes a message role for headings
+func roleTitle(role string) string {
+	if role == "" {
+		return role
+	}
+	return strings.ToUpper(role[:1]) + role[1:]
+}
+
+// htmlExportTemplate renders the conversation as a standalone HTML page
+var htmlExportTemplate = template.Must(template.New("conversation").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Conversation</title>
+<style>
+body { font-family: sans-serif; max-width: 48em; margin: 2em auto; }
+.message { margin-bottom: 1.5em; }
+.role { font-weight: bold; }
+.content { white-space: pre-wrap; }
+</style>
+</head>
+<body>
+{{range .}}<div class="message {{.Role}}">
+<div class="role">{{.Title}}</div>
+<div class="content">{{.Text}}</div>
+</div>
+{{end}}</body>
+</html>
+`))
+
+// exportHTML writes the conversation as a standalone HTML page
+func exportHTML(m *Memory, w io.Writer) error {
+	type htmlMessage struct {
+		Role, Title, Text string
+	}
+	messages := make([]htmlMessage, 0, len(m.messages))
+	for _, message := range m.messages {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			text = fmt.Sprintf("<%v>", err)
+		}
+		role := llm.MessageRole(message)
+		messages = append(messages, htmlMessage{Role: role, Title: roleTitle(role), Text: text})
+	}
+	if err := htmlExportTemplate.Execute(w, messages); err != nil {
+		return fmt.Errorf("failed to write conversation: %w", err)
+	}
+	return nil
+}
@@ -1,39 +1,245 @@
 package memory
 
 import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+
+	"llm-go/internal/llm"
+
 	"github.com/openai/openai-go"
 )
 
+// maxStringMessageLength is the number of characters shown per message by String
+const maxStringMessageLength = 200
+
 // Memory manages conversation history
 type Memory struct {
 	messages []openai.ChatCompletionMessageParamUnion
+	// meta holds the details of each message not sent to the model, aligned with messages
+	meta       []messageMeta
+	systemRole string
+	// tokenCounter estimates token counts (nil = llm.CountTokens heuristic)
+	tokenCounter TokenCounter
+	// windowSize is the maximum number of user+assistant turns kept (0 = unlimited)
+	windowSize int
+	// thinkStartTag and thinkEndTag delimit the thinking blocks of stored messages
+	thinkStartTag string
+	thinkEndTag   string
+	// currentDateTime formats the time for prompt templates (nil = RFC 1123)
+	currentDateTime func() string
+	// colorizeRole colors the role labels of PrettyPrint (nil = plain labels)
+	colorizeRole RoleColorizer
+}
+
+// RoleColorizer returns label colored for the message role
+type RoleColorizer func(role, label string) string
+
+// messageMeta is what the conversation history keeps about a message besides its content
+type messageMeta struct {
+	addedAt time.Time
+	// thinking holds the thinking blocks removed from an assistant response
+	thinking string
+}
+
+// TokenCounter estimates the number of tokens of a list of messages
+type TokenCounter interface {
+	CountTokens(messages []openai.ChatCompletionMessageParamUnion) int
 }
 
 // NewMemory creates a new memory instance
 func NewMemory() *Memory {
 	return &Memory{
-		messages: make([]openai.ChatCompletionMessageParamUnion, 0),
+		messages:      make([]openai.ChatCompletionMessageParamUnion, 0),
+		systemRole:    llm.SystemRoleSystem,
+		thinkStartTag: llm.DefaultThinkStartTag,
+		thinkEndTag:   llm.DefaultThinkEndTag,
 	}
 }
 
+// NewSlidingWindowMemory creates a memory keeping at most windowSize user+assistant turns.
+// Adding a user message beyond the window evicts the oldest turn as a whole; system
+// messages are kept and do not count towards the window.
+func NewSlidingWindowMemory(windowSize int) *Memory {
+	m := NewMemory()
+	m.windowSize = max(windowSize, 0)
+	return m
+}
+
 // AddMessage adds a message to the conversation history
 func (m *Memory) AddMessage(message openai.ChatCompletionMessageParamUnion) {
+	m.addMessage(message, "")
+}
+
+// addMessage adds a message with the thinking removed from it to the conversation history
+func (m *Memory) addMessage(message openai.ChatCompletionMessageParamUnion, thinking string) {
+	if message.OfUser != nil && m.windowSize > 0 {
+		for m.countTurns() >= m.windowSize {
+			m.evictOldestTurn()
+		}
+	}
 	m.messages = append(m.messages, message)
+	m.meta = append(m.meta, messageMeta{addedAt: time.Now(), thinking: thinking})
+}
+
+// countTurns returns the number of turns, each starting with a user message
+func (m *Memory) countTurns() int {
+	turns := 0
+	for _, message := range m.messages {
+		if message.OfUser != nil {
+			turns++
+		}
+	}
+	return turns
+}
+
+// evictOldestTurn removes the first user message and the non-system messages that follow
+// it up to the next user message
+func (m *Memory) evictOldestTurn() {
+	start := slices.IndexFunc(m.messages, func(message openai.ChatCompletionMessageParamUnion) bool {
+		return message.OfUser != nil
+	})
+	if start < 0 {
+		return
+	}
+	messages := append([]openai.ChatCompletionMessageParamUnion(nil), m.messages[:start]...)
+	meta := append([]messageMeta(nil), m.meta[:start]...)
+	i := start + 1
+	for ; i < len(m.messages) && m.messages[i].OfUser == nil; i++ {
+		if isSystemMessage(m.messages[i]) {
+			messages = append(messages, m.messages[i])
+			meta = append(meta, m.meta[i])
+		}
+	}
+	m.messages = append(messages, m.messages[i:]...)
+	m.meta = append(meta, m.meta[i:]...)
 }
 
 // AddUserMessage adds a user message to the conversation history
 func (m *Memory) AddUserMessage(content string) {
-	m.messages = append(m.messages, openai.UserMessage(content))
+	m.AddMessage(openai.UserMessage(content))
 }
 
 // AddAssistantMessage adds an assistant message to the conversation history
 func (m *Memory) AddAssistantMessage(content string) {
-	m.messages = append(m.messages, openai.AssistantMessage(content))
+	m.AddMessage(openai.AssistantMessage(content))
+}
+
+// AddAssistantMessageWithThinking adds an assistant message whose thinking blocks were
+// removed from content. The thinking is not sent back to the model but is kept for
+// ExportMarkdown.
+func (m *Memory) AddAssistantMessageWithThinking(content, thinking string) {
+	m.addMessage(openai.AssistantMessage(content), thinking)
+}
+
+// SetSystemRole sets the role used for system instructions: "system" or "developer".
+// System messages already in the conversation history are converted to the new role.
+func (m *Memory) SetSystemRole(role string) {
+	m.systemRole = role
+	for i, message := range m.messages {
+		if !isSystemMessage(message) {
+			continue
+		}
+		if text, err := llm.MessageText(message); err == nil {
+			m.messages[i] = m.systemMessage(text)
+		}
+	}
+}
+
+// SetThinkingTags sets the delimiters of the thinking blocks in stored messages
+func (m *Memory) SetThinkingTags(startTag, endTag string) {
+	m.thinkStartTag = startTag
+	m.thinkEndTag = endTag
+}
+
+// SetDateTimeFormatter sets the function formatting the currentDateTime value of
+// SetSystemPromptFromTemplate
+func (m *Memory) SetDateTimeFormatter(format func() string) {
+	m.currentDateTime = format
+}
+
+// SetRoleColorizer sets the function coloring the role labels of PrettyPrint
+func (m *Memory) SetRoleColorizer(colorize RoleColorizer) {
+	m.colorizeRole = colorize
 }
 
 // AddSystemMessage adds a system message to the conversation history
 func (m *Memory) AddSystemMessage(content string) {
-	m.messages = append(m.messages, openai.SystemMessage(content))
+	m.AddMessage(m.systemMessage(content))
+}
+
+// systemMessage creates a system instruction message using the configured role
+func (m *Memory) systemMessage(content string) openai.ChatCompletionMessageParamUnion {
+	if m.systemRole == llm.SystemRoleDeveloper {
+		return openai.DeveloperMessage(content)
+	}
+	return openai.SystemMessage(content)
+}
+
+// isSystemMessage reports whether the message holds system instructions
+func isSystemMessage(message openai.ChatCompletionMessageParamUnion) bool {
+	return message.OfSystem != nil || message.OfDeveloper != nil
+}
+
+// SetSystemPrompt sets the system prompt of the conversation, see SetSystemMessage
+func (m *Memory) SetSystemPrompt(content string) {
+	m.SetSystemMessage(content)
+}
+
+// SetSystemMessage replaces the first system message, or inserts one at the start of the
+// conversation history if none exists
+func (m *Memory) SetSystemMessage(content string) {
+	for i, message := range m.messages {
+		if isSystemMessage(message) {
+			m.messages[i] = m.systemMessage(content)
+			return
+		}
+	}
+	m.messages = append([]openai.ChatCompletionMessageParamUnion{m.systemMessage(content)}, m.messages...)
+	m.meta = append([]messageMeta{{addedAt: time.Now()}}, m.meta...)
+}
+
+// GetSystemPrompt returns the text of the first system message, and false if the
+// conversation history has none
+func (m *Memory) GetSystemPrompt() (string, bool) {
+	for _, message := range m.messages {
+		if isSystemMessage(message) {
+			text, err := llm.MessageText(message)
+			return text, err == nil
+		}
+	}
+	return "", false
+}
+
+// SetSystemPromptFromTemplate renders tmpl with text/template and sets the result as the
+// system prompt. The currentDateTime key is always available alongside the given data.
+func (m *Memory) SetSystemPromptFromTemplate(tmpl string, data map[string]string) error {
+	t, err := template.New("system-prompt").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("failed to parse system prompt template: %w", err)
+	}
+
+	currentDateTime := time.Now().Format(time.RFC1123)
+	if m.currentDateTime != nil {
+		currentDateTime = m.currentDateTime()
+	}
+	values := map[string]string{
+		"currentDateTime": currentDateTime,
+	}
+	for key, value := range data {
+		values[key] = value
+	}
+
+	var rendered strings.Builder
+	if err := t.Execute(&rendered, values); err != nil {
+		return fmt.Errorf("failed to render system prompt template: %w", err)
+	}
+	m.SetSystemPrompt(rendered.String())
+	return nil
 }
 
 // GetMessages returns the conversation history
@@ -44,9 +250,179 @@ func (m *Memory) GetMessages() []openai.ChatCompletionMessageParamUnion {
 // Clear clears the conversation history
 func (m *Memory) Clear() {
 	m.messages = make([]openai.ChatCompletionMessageParamUnion, 0)
+	m.meta = nil
+}
+
+// GetConversationAge returns the time since the oldest message was added, or 0 without messages
+func (m *Memory) GetConversationAge() time.Duration {
+	if len(m.meta) == 0 {
+		return 0
+	}
+	return time.Since(m.createdAt())
+}
+
+// GetMessageAge returns the time since the message at index was added, or 0 for an invalid index
+func (m *Memory) GetMessageAge(index int) time.Duration {
+	if index < 0 || index >= len(m.meta) {
+		return 0
+	}
+	return time.Since(m.meta[index].addedAt)
+}
+
+// createdAt returns the time the oldest message was added
+func (m *Memory) createdAt() time.Time {
+	oldest := m.meta[0].addedAt
+	for _, meta := range m.meta[1:] {
+		if meta.addedAt.Before(oldest) {
+			oldest = meta.addedAt
+		}
+	}
+	return oldest
+}
+
+// WordCount returns the number of whitespace-separated words across all messages
+func (m *Memory) WordCount() int {
+	words := 0
+	for _, message := range m.messages {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			continue
+		}
+		words += len(strings.Fields(text))
+	}
+	return words
+}
+
+// CharCount returns the number of characters across all messages
+func (m *Memory) CharCount() int {
+	chars := 0
+	for _, message := range m.messages {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			continue
+		}
+		chars += utf8.RuneCountInString(text)
+	}
+	return chars
 }
 
 // Len returns the number of messages in the conversation history
 func (m *Memory) Len() int {
 	return len(m.messages)
 }
+
+// SetTokenCounter replaces the token estimate used by EstimateTokens and TruncateToTokenLimit
+func (m *Memory) SetTokenCounter(counter TokenCounter) {
+	m.tokenCounter = counter
+}
+
+// EstimateTokens returns the estimated number of tokens of the conversation history
+func (m *Memory) EstimateTokens() int {
+	if m.tokenCounter != nil {
+		return m.tokenCounter.CountTokens(m.messages)
+	}
+	tokens, err := llm.CountTokens(m.messages)
+	if err != nil {
+		return 0
+	}
+	return tokens
+}
+
+// TruncateToTokenLimit removes the oldest non-system messages until the estimated token
+// count fits in maxTokens. System messages are always kept. It returns the number of
+// messages removed.
+func (m *Memory) TruncateToTokenLimit(maxTokens int) int {
+	removed := 0
+	for m.EstimateTokens() > maxTokens {
+		oldest := slices.IndexFunc(m.messages, func(message openai.ChatCompletionMessageParamUnion) bool {
+			return !isSystemMessage(message)
+		})
+		if oldest < 0 {
+			break
+		}
+		m.messages = slices.Delete(m.messages, oldest, oldest+1)
+		m.meta = slices.Delete(m.meta, oldest, oldest+1)
+		removed++
+	}
+	return removed
+}
+
+// Compact keeps the first keepFirst and the last keepLast messages and removes everything
+// in between, replacing the gap with a marker message. System messages are always kept.
+// It returns the number of messages removed.
+func (m *Memory) Compact(keepFirst, keepLast int) int {
+	keepFirst = max(keepFirst, 0)
+	keepLast = max(keepLast, 0)
+	if keepFirst+keepLast >= len(m.messages) {
+		return 0
+	}
+
+	tailStart := len(m.messages) - keepLast
+	compacted := make([]openai.ChatCompletionMessageParamUnion, 0, keepFirst+keepLast+1)
+	compacted = append(compacted, m.messages[:keepFirst]...)
+	meta := make([]messageMeta, 0, keepFirst+keepLast+1)
+	meta = append(meta, m.meta[:keepFirst]...)
+
+	// Keep system messages from the omitted range so instructions are never lost
+	removed := 0
+	var omittedAt time.Time
+	for i, message := range m.messages[keepFirst:tailStart] {
+		if isSystemMessage(message) {
+			compacted = append(compacted, message)
+			meta = append(meta, m.meta[keepFirst+i])
+			continue
+		}
+		if removed == 0 {
+			omittedAt = m.meta[keepFirst+i].addedAt
+		}
+		removed++
+	}
+	if removed == 0 {
+		return 0
+	}
+
+	// The marker takes the time of the first omitted message
+	compacted = append(compacted, openai.UserMessage(fmt.Sprintf("[... %d messages omitted ...]", removed)))
+	compacted = append(compacted, m.messages[tailStart:]...)
+	meta = append(meta, messageMeta{addedAt: omittedAt})
+	meta = append(meta, m.meta[tailStart:]...)
+	m.messages = compacted
+	m.meta = meta
+	return removed
+}
+
+// String formats the conversation history as a plain-text dialogue, one line per message
+func (m *Memory) String() string {
+	var b strings.Builder
+	for _, message := range m.messages {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			text = fmt.Sprintf("<%v>", err)
+		}
+		if runes := []rune(text); len(runes) > maxStringMessageLength {
+			text = string(runes[:maxStringMessageLength]) + "..."
+		}
+		fmt.Fprintf(&b, "[%s]: %s\n", llm.MessageRole(message), text)
+	}
+	return b.String()
+}
+
+// PrettyPrint writes the full conversation to w, coloring the role labels with the
+// colorizer of SetRoleColorizer when colorize is set
+func (m *Memory) PrettyPrint(w io.Writer, colorize bool) error {
+	for _, message := range m.messages {
+		text, err := llm.MessageText(message)
+		if err != nil {
+			text = fmt.Sprintf("<%v>", err)
+		}
+		role := llm.MessageRole(message)
+		label := "[" + role + "]"
+		if colorize && m.colorizeRole != nil {
+			label = m.colorizeRole(role, label)
+		}
+		if _, err := fmt.Fprintf(w, "%s: %s\n", label, text); err != nil {
+			return fmt.Errorf("failed to write conversation: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package memory
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"llm-go/internal/llm"
+)
+
+func TestSetSystemPromptFromTemplate(t *testing.T) {
+	m := NewMemory()
+	m.SetDateTimeFormatter(func() string { return "Tuesday 1 September 2025, 10:17 AM" })
+
+	err := m.SetSystemPromptFromTemplate("Hello {{.name}}. Today is {{.currentDateTime}}.", map[string]string{"name": "Ada"})
+	if err != nil {
+		t.Fatalf("SetSystemPromptFromTemplate() error = %v", err)
+	}
+	var b strings.Builder
+	if err := m.PrettyPrint(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[system]: Hello Ada. Today is Tuesday 1 September 2025, 10:17 AM.\n"; b.String() != want {
+		t.Errorf("conversation = %q, want %q", b.String(), want)
+	}
+}
+
+func TestPrettyPrintColorizer(t *testing.T) {
+	m := NewMemory()
+	m.AddUserMessage("Hi")
+	m.AddAssistantMessage("Hello")
+	m.SetRoleColorizer(func(role, label string) string { return "<" + role + ">" + label })
+
+	tests := []struct {
+		name     string
+		colorize bool
+		want     string
+	}{
+		{"plain", false, "[user]: Hi\n[assistant]: Hello\n"},
+		{"colorized", true, "<user>[user]: Hi\n<assistant>[assistant]: Hello\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			if err := m.PrettyPrint(&b, tt.colorize); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != tt.want {
+				t.Errorf("PrettyPrint() = %q, want %q", b.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	saved := NewMemory()
+	saved.AddSystemMessage("Saved prompt")
+	for _, q := range []string{"one", "two", "three"} {
+		saved.AddUserMessage(q)
+		saved.AddAssistantMessage("answer " + q)
+	}
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := saved.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewSlidingWindowMemory(2)
+	m.SetSystemRole(llm.SystemRoleDeveloper)
+	if err := m.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	if prompt, ok := m.GetSystemPrompt(); !ok || prompt != "Saved prompt" {
+		t.Errorf("GetSystemPrompt() = %q, %v, want %q, true", prompt, ok, "Saved prompt")
+	}
+	messages := m.GetMessages()
+	if messages[0].OfDeveloper == nil {
+		t.Error("loaded system message was not converted to the developer role")
+	}
+	var b strings.Builder
+	if err := m.PrettyPrint(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "[developer]: Saved prompt\n[user]: two\n[assistant]: answer two\n[user]: three\n[assistant]: answer three\n"
+	if b.String() != want {
+		t.Errorf("conversation = %q, want %q", b.String(), want)
+	}
+}
+
+func TestSaveLoadKeepsTimestamps(t *testing.T) {
+	saved := NewMemory()
+	saved.AddSystemMessage("Prompt")
+	saved.AddUserMessage("Hi")
+	saved.AddAssistantMessage("Hello")
+	start := time.Now().Add(-2 * time.Hour)
+	for i := range saved.meta {
+		saved.meta[i].addedAt = start.Add(time.Duration(i) * time.Minute)
+	}
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := saved.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMemory()
+	if err := m.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	if m.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", m.Len())
+	}
+	for i, want := range saved.meta {
+		if !m.meta[i].addedAt.Equal(want.addedAt) {
+			t.Errorf("timestamp %d = %v, want %v", i, m.meta[i].addedAt, want.addedAt)
+		}
+	}
+	if age := m.GetConversationAge(); age < 2*time.Hour {
+		t.Errorf("GetConversationAge() = %v, want at least 2h", age)
+	}
+	if age := m.GetMessageAge(2); age < 2*time.Hour-2*time.Minute || age >= 2*time.Hour {
+		t.Errorf("GetMessageAge(2) = %v, want about 1h58m", age)
+	}
+	if text, err := llm.MessageText(m.GetMessages()[2]); err != nil || text != "Hello" || m.GetMessages()[2].OfAssistant == nil {
+		t.Errorf("message 2 = %q, %v, want the assistant message %q", text, err, "Hello")
+	}
+}
+
+func TestLoadFromFileLegacyArray(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chat.json")
+	legacy := `[{"role":"user","content":"Hi"},{"role":"assistant","content":"Hello"}]`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMemory()
+	if err := m.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+	var b strings.Builder
+	if err := m.PrettyPrint(&b, false); err != nil {
+		t.Fatal(err)
+	}
+	if want := "[user]: Hi\n[assistant]: Hello\n"; b.String() != want {
+		t.Errorf("conversation = %q, want %q", b.String(), want)
+	}
+	if age := m.GetConversationAge(); age > time.Minute {
+		t.Errorf("GetConversationAge() = %v, want a fresh conversation", age)
+	}
+}
+
+func TestWordAndCharCount(t *testing.T) {
+	m := NewMemory()
+	if m.WordCount() != 0 || m.CharCount() != 0 {
+		t.Fatalf("empty memory counts = %d words, %d characters, want 0", m.WordCount(), m.CharCount())
+	}
+	m.AddSystemMessage("Be brief.")
+	m.AddUserMessage("  Wie  groß ist\tder Eiffelturm? ")
+	m.AddAssistantMessage("330 m")
+
+	if got := m.WordCount(); got != 9 {
+		t.Errorf("WordCount() = %d, want 9", got)
+	}
+	// Characters are counted as runes, including whitespace
+	if got, want := m.CharCount(), 9+32+5; got != want {
+		t.Errorf("CharCount() = %d, want %d", got, want)
+	}
+}
+
+func TestExportMarkdownThinking(t *testing.T) {
+	m := NewMemory()
+	m.AddUserMessage("Hi")
+	m.AddAssistantMessageWithThinking("Hello", "Greet back")
+	m.AddUserMessage("Again")
+	m.AddAssistantMessage("<think>Still greeting</think>Hello again")
+	path := filepath.Join(t.TempDir(), "chat.json")
+	if err := m.SaveToFile(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewMemory()
+	if err := loaded.LoadFromFile(path); err != nil {
+		t.Fatalf("LoadFromFile() error = %v", err)
+	}
+
+	for name, m := range map[string]*Memory{"stored": m, "loaded": loaded} {
+		t.Run(name, func(t *testing.T) {
+			if text, _ := llm.MessageText(m.GetMessages()[1]); text != "Hello" {
+				t.Errorf("assistant message = %q, want the thinking kept out of it", text)
+			}
+			var b strings.Builder
+			if err := m.ExportMarkdown(&b); err != nil {
+				t.Fatal(err)
+			}
+			want := "**User:**\n\nHi\n\n" +
+				"---\n\n**Assistant:**\n\n<details>\n<summary>Thinking</summary>\n\nGreet back\n\n</details>\n\nHello\n\n" +
+				"---\n\n**User:**\n\nAgain\n\n" +
+				"---\n\n**Assistant:**\n\n<details>\n<summary>Thinking</summary>\n\nStill greeting\n\n</details>\n\nHello again\n\n"
+			if b.String() != want {
+				t.Errorf("ExportMarkdown() = %q, want %q", b.String(), want)
+			}
+		})
+	}
+}
@@ -1,121 +1,268 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
 
 	"llm-go/internal/cli"
 	"llm-go/internal/config"
 	"llm-go/internal/llm"
+	auditlog "llm-go/internal/log"
 	"llm-go/internal/memory"
-)
 
-const (
-	// Do not change
-	startThinkTag = "<think>"
-	endThinkTag   = "</think>"
+	"github.com/openai/openai-go"
+	"gopkg.in/yaml.v3"
 )
 
-// removeThinkingBlocks removes thinking blocks (including tags and content) from responses
-// and returns only the actual response content after the thinking block
-func removeThinkingBlocks(s string) string {
-
-	startIdx := strings.Index(s, startThinkTag)
-	if startIdx == -1 {
-		return s // No thinking block found, return original
+func main() {
+	router := cli.NewRouter("chat")
+	router.Register("chat", runChat)
+	if err := router.Dispatch(os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
 	}
+}
 
-	// Find the end of the thinking block
-	afterStart := s[startIdx+len(startThinkTag):]
-	endIdx := strings.Index(afterStart, endThinkTag)
-	if endIdx == -1 {
-		return s // No end tag found, return original
+// runChat runs the chat command, the default when no subcommand is given
+func runChat(args []string) error {
+	cliHandler := initCLI(args)
+	// Tag every log record with the conversation for correlation with the audit log
+	slog.SetDefault(slog.Default().With("conversation_id", cliHandler.GetConversationID()))
+	mode, err := cliHandler.GetRunMode()
+	if err != nil {
+		return err
 	}
 
-	// Calculate position after the thinking block
-	afterEnd := startIdx + len(startThinkTag) + endIdx + len(endThinkTag)
+	switch mode {
+	case cli.InitMode:
+		return initEnvFile(cliHandler)
+	case cli.ListProfilesMode:
+		listProfiles(cliHandler)
+	case cli.ModelInfoMode:
+		_, client := initSession(cliHandler)
+		displayModelInfo(cliHandler, client)
+	case cli.ModelfileMode:
+		_, client := initSession(cliHandler)
+		modelfile, err := client.DownloadModelfile(context.Background(), cliHandler.GetModelfile())
+		if err != nil {
+			cliHandler.ShowError(err)
+			os.Exit(1)
+		}
+		fmt.Println(modelfile)
+	case cli.ModelSizeMode:
+		cfg, client := initSession(cliHandler)
+		size, err := client.GetModelSize(context.Background(), cfg.Model)
+		if err != nil {
+			cliHandler.ShowError(err)
+			os.Exit(1)
+		}
+		fmt.Println(cli.FormatSize(size))
+	case cli.VRAMUsageMode:
+		_, client := initSession(cliHandler)
+		usage, err := client.GetLoadedModelVRAMUsage(context.Background())
+		if err != nil {
+			cliHandler.ShowError(err)
+			os.Exit(1)
+		}
+		fmt.Println(cli.FormatSize(usage))
+	case cli.PushModelMode:
+		_, client := initSession(cliHandler)
+		pushModel(cliHandler, client)
+	default:
+		cfg, client := initSession(cliHandler)
+		mem := initMemory(cfg, client.GetCurrentConfig().SystemPromptRole)
+		mem.SetThinkingTags(client.GetThinkingTags())
+		if cfg.ConversationFile != "" {
+			if err := loadConversationFile(client, mem, cfg.ConversationFile); err != nil {
+				return err
+			}
+		}
+		if cliHandler.GetResumeID() != "" {
+			resumeConversation(cliHandler, mem)
+		}
+		if addr := cliHandler.GetMetricsAddr(); addr != "" {
+			startMetricsServer(addr)
+		}
+		format, file := cliHandler.GetConversationExport()
+		if format != "" && !slices.Contains(memory.ExportFormats(), format) {
+			return fmt.Errorf("unsupported format %q for --conversation-export, expected one of: %s", format, strings.Join(memory.ExportFormats(), ", "))
+		}
+		runConversationLoop(cliHandler, cfg, client, mem, watchSystemPrompt(cliHandler))
+		if cfg.ConversationFile != "" {
+			if err := mem.SaveToFile(cfg.ConversationFile); err != nil {
+				return err
+			}
+		}
+		if cliHandler.GetConversationStats() {
+			printSessionReport(cliHandler, client.GetSessionReport())
+		}
+		if format != "" {
+			if err := exportConversation(mem, format, file); err != nil {
+				return err
+			}
+		}
+		if path := cliHandler.GetExportMarkdown(); path != "" {
+			return exportConversation(mem, "markdown", path)
+		}
+	}
+	return nil
+}
 
-	// Return only content after the thinking block, trimmed
-	return strings.TrimSpace(s[afterEnd:])
+// initEnvFile writes the .env template for --init, refusing to replace an existing file
+// unless --force is set
+func initEnvFile(cliHandler *cli.CLI) error {
+	const path = ".env"
+	if _, err := os.Stat(path); err == nil && !cliHandler.GetForce() {
+		return fmt.Errorf("%s already exists, use --force to overwrite it", path)
+	}
+	if err := config.GenerateDefaultEnvFile(path); err != nil {
+		return err
+	}
+	fmt.Fprintln(cliHandler.GetWriter(), "Edit .env to configure llm-go")
+	return nil
 }
 
-// extractThinkingBlocks extracts thinking blocks (including tags and content) from responses
-func extractThinkingBlocks(s string) string {
-	startIdx := strings.Index(s, startThinkTag)
-	if startIdx == -1 {
-		return "" // No thinking block found
+// displayModelInfo shows the model information, as the raw API response in JSON mode
+func displayModelInfo(cliHandler *cli.CLI, client *llm.Client) {
+	if !cliHandler.IsStructuredOutput() {
+		if err := client.DisplayModelInfo(); err != nil {
+			cliHandler.ShowError(err)
+			os.Exit(1)
+		}
+		return
 	}
 
-	// Find the end of the thinking block
-	afterStart := s[startIdx+len(startThinkTag):]
-	endIdx := strings.Index(afterStart, endThinkTag)
-	if endIdx == -1 {
-		return "" // No end tag found
+	data, err := client.GetModelInfoJSON(context.Background())
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+	if cliHandler.GetOutput() == cli.OutputYAML {
+		var info any
+		if err := json.Unmarshal(data, &info); err != nil {
+			cliHandler.ShowError(fmt.Errorf("failed to parse model info: %w", err))
+			os.Exit(1)
+		}
+		if data, err = yaml.Marshal(info); err != nil {
+			cliHandler.ShowError(fmt.Errorf("error marshaling YAML: %w", err))
+			os.Exit(1)
+		}
+		fmt.Print(string(data))
+		return
 	}
+	fmt.Println(string(data))
+}
 
-	// Calculate position after the thinking block
-	afterEnd := startIdx + len(startThinkTag) + endIdx + len(endThinkTag)
+// pushModel asks for confirmation and pushes the model given by --push-model
+func pushModel(cliHandler *cli.CLI, client *llm.Client) {
+	model := cliHandler.GetPushModel()
+	confirmed, err := cliHandler.Confirm(fmt.Sprintf("Push model '%s' to the Ollama registry?", model))
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+	if !confirmed {
+		fmt.Println("Push cancelled")
+		return
+	}
 
-	// Return only the thinking block content (including tags)
-	return strings.TrimSpace(s[startIdx:afterEnd])
+	lastStatus := ""
+	err = client.PushModel(context.Background(), model, func(progress llm.PullProgress) {
+		if progress.Status != lastStatus {
+			fmt.Printf("Push status: %s\n", progress.Status)
+			lastStatus = progress.Status
+		}
+	})
+	if err != nil {
+		cliHandler.ShowError(fmt.Errorf("error pushing model: %w", err))
+		os.Exit(1)
+	}
+	fmt.Printf("Successfully pushed model '%s'\n", model)
 }
 
-func main() {
-	cliHandler := initCLI()
+// initSession loads configuration, validates the model and creates the LLM client
+func initSession(cliHandler *cli.CLI) (*config.Config, *llm.Client) {
 	cfg := loadConfig(cliHandler)
+	client := initLLMClient(cfg)
 	// Handle model pulling and validation if model is specified
 	if cliHandler.GetModel() != "" {
-		// Convert OpenAI BaseURL to Ollama BaseURL by removing /v1 suffix
-		ollamaBaseURL := strings.TrimSuffix(cfg.BaseURL, "/v1")
+		ensureModelAvailable(cliHandler, cfg, client)
+	}
+	return cfg, client
+}
 
-		// If --pull flag is set, attempt to pull the model first
-		if cliHandler.GetPullModel() {
-			fmt.Printf("Pulling model '%s'...\n", cfg.Model)
-			err := llm.PullModel(ollamaBaseURL, cfg.APIKey, cfg.Model)
-			if err != nil {
-				fmt.Printf("Error pulling model: %v\n", err)
-				os.Exit(1)
-			}
-			fmt.Printf("Successfully pulled model '%s'\n", cfg.Model)
-			// Skip existence check since we just pulled the model
-		} else {
-			// Validate model existence only if we didn't pull
-			exists, err := llm.CheckModelExists(ollamaBaseURL, cfg.APIKey, cfg.Model)
-			if err != nil {
-				fmt.Printf("Error checking model existence: %v\n", err)
-				os.Exit(1)
-			}
-			if !exists {
-				fmt.Printf("Error: Model '%s' not found on Ollama server\n", cfg.Model)
-				fmt.Println("You can try pulling it with the --pull flag")
-				os.Exit(1)
+// ensureModelAvailable pulls the model when --pull is set, otherwise verifies it exists
+func ensureModelAvailable(cliHandler *cli.CLI, cfg *config.Config, client *llm.Client) {
+	// If --pull flag is set, attempt to pull the model first
+	if cliHandler.GetPullModel() {
+		fmt.Printf("Pulling model '%s'...\n", cfg.Model)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		lastStatus := ""
+		err := client.PullModel(ctx, cfg.Model, func(progress llm.PullProgress) {
+			if progress.Status != lastStatus {
+				fmt.Printf("Pull status: %s\n", progress.Status)
+				lastStatus = progress.Status
 			}
+		})
+		if err != nil {
+			fmt.Printf("Error pulling model: %v\n", err)
+			os.Exit(1)
 		}
+		fmt.Printf("Successfully pulled model '%s'\n", cfg.Model)
+		// Skip existence check since we just pulled the model
+		return
 	}
 
-	client := initLLMClient(cfg)
-
-	// Handle model info display
-	if cliHandler.GetShowModelInfo() {
-		if err := client.DisplayModelInfo(); err != nil {
-			fmt.Printf("Error: %v\n", err)
-			os.Exit(1)
+	// Validate model existence only if we didn't pull
+	done := make(chan struct{})
+	progressDone := make(chan struct{})
+	go func() {
+		defer close(progressDone)
+		if cliHandler.GetQuiet() {
+			<-done
+			return
 		}
+		cli.ShowProgress(fmt.Sprintf("Checking model '%s'", cfg.Model), done)
+	}()
+	exists, err := client.CheckModelExists(cfg.Model)
+	close(done)
+	<-progressDone
+	if errors.Is(err, llm.ErrOllamaAPIUnavailable) {
+		// Other providers validate the model with the first request
 		return
 	}
-
-	mem := initMemory(cfg)
-	runConversationLoop(cliHandler, client, mem)
+	if err != nil {
+		fmt.Printf("Error checking model existence: %v\n", err)
+		os.Exit(1)
+	}
+	if !exists {
+		fmt.Printf("Error: Model '%s' not found on Ollama server\n", cfg.Model)
+		fmt.Println("You can try pulling it with the --pull flag")
+		os.Exit(1)
+	}
 }
 
 // initCLI initializes and parses command line flags
-func initCLI() *cli.CLI {
+func initCLI(args []string) *cli.CLI {
 	cliHandler := cli.NewCLI()
-	cliHandler.ParseFlags()
+	cliHandler.ParseFlags(args)
+	if err := cliHandler.ValidateFlags(); err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
 	return cliHandler
 }
 
@@ -136,35 +283,169 @@ func loadConfig(cliHandler *cli.CLI) *config.Config {
 	}
 	// If no system prompt file is provided, systemPrompt remains empty
 
+	var responseSchema json.RawMessage
+	if schemaFile := cliHandler.GetResponseSchemaFile(); schemaFile != "" {
+		var err error
+		responseSchema, err = config.ReadResponseSchema(schemaFile)
+		if err != nil {
+			cliHandler.ShowError(err)
+			os.Exit(1)
+		}
+	}
+
+	var messageTemplate *template.Template
+	if templateFile := cliHandler.GetMessageTemplateFile(); templateFile != "" {
+		var err error
+		messageTemplate, err = config.ReadMessageTemplate(templateFile)
+		if err != nil {
+			cliHandler.ShowError(err)
+			os.Exit(1)
+		}
+	}
+
 	// Load configuration with system prompt, model, and temperature
-	cfg := config.LoadConfig(systemPrompt, cliHandler.GetModel(), cliHandler.GetTemperature())
+	cfg, err := config.LoadConfig(config.Options{
+		ConfigFile:              cliHandler.GetConfigFile(),
+		Profile:                 cliHandler.GetProfile(),
+		SystemPrompt:            systemPrompt,
+		Model:                   cliHandler.GetModel(),
+		Temperature:             cliHandler.GetTemperature(),
+		TopP:                    cliHandler.GetTopP(),
+		PresencePenalty:         cliHandler.GetPresencePenalty(),
+		FrequencyPenalty:        cliHandler.GetFrequencyPenalty(),
+		Seed:                    cliHandler.GetSeed(),
+		ReasoningEffort:         cliHandler.GetReasoningEffort(),
+		NumCtx:                  cliHandler.GetNumCtx(),
+		MaxTokens:               cliHandler.GetMaxTokens(),
+		StopSequences:           cliHandler.GetStopSequences(),
+		CostWarningThreshold:    cliHandler.GetCostWarningThreshold(),
+		ResponseSchema:          responseSchema,
+		MessageTemplate:         messageTemplate,
+		StreamBufferSize:        cliHandler.GetStreamBuffer(),
+		ConversationFile:        cliHandler.GetConversationFile(),
+		RequestTimeout:          cliHandler.GetRequestTimeout(),
+		ParallelToolCalls:       cliHandler.GetParallelToolCalls(),
+		UserAgent:               cliHandler.GetUserAgent(),
+		DisableTotalUsageOnExit: cliHandler.GetNoTotalUsage(),
+		Verbose:                 cliHandler.GetVerbose(),
+		DisableStreaming:        cliHandler.GetNoStream(),
+		ThinkStartTag:           cliHandler.GetThinkStartTag(),
+		ThinkEndTag:             cliHandler.GetThinkEndTag(),
+	})
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
 
-	// Validate API key
+	// Validate API key, asking for one on first run in interactive mode
 	if cfg.APIKey == "" {
-		cliHandler.ShowError(nil)
-		os.Exit(1)
+		if !cliHandler.IsInteractive() {
+			cliHandler.ShowError(errors.New("no API key configured"))
+			os.Exit(1)
+		}
+		cfg.APIKey = promptForAPIKey(cliHandler)
 	}
 
 	return &cfg
 }
 
+// promptForAPIKey asks for an API key and offers to save it to .env
+func promptForAPIKey(cliHandler *cli.CLI) string {
+	apiKey, err := cliHandler.PromptForAPIKey()
+	if err != nil {
+		if errors.Is(err, cli.ErrNotTerminal) {
+			err = errors.New("no API key configured")
+		}
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+
+	save, err := cliHandler.Confirm("Save API key to .env in the current directory?")
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+	if save {
+		if err := config.SaveAPIKey(".env", apiKey); err != nil {
+			cliHandler.ShowError(err)
+		} else {
+			fmt.Println("API key saved to .env")
+		}
+	}
+	return apiKey
+}
+
+// listProfiles prints the names of the profiles defined in the config file
+func listProfiles(cliHandler *cli.CLI) {
+	fileCfg, err := config.LoadConfigFile(cliHandler.GetConfigFile())
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+	names := fileCfg.ProfileNames()
+	if len(names) == 0 {
+		fmt.Println("No profiles defined")
+		return
+	}
+	for _, name := range names {
+		fmt.Println(name)
+	}
+}
+
 // initLLMClient creates and configures the LLM client
 func initLLMClient(cfg *config.Config) *llm.Client {
 	// Create LLM client
 	llmConfig := llm.Config{
-		APIKey:       cfg.APIKey,
-		BaseURL:      cfg.BaseURL,
-		Model:        cfg.Model,
-		Temperature:  cfg.Temperature,
-		SystemPrompt: cfg.SystemPrompt,
+		APIKey:               cfg.APIKey,
+		BaseURL:              cfg.BaseURL,
+		Model:                cfg.Model,
+		Temperature:          cfg.Temperature,
+		TopP:                 cfg.TopP,
+		PresencePenalty:      cfg.PresencePenalty,
+		FrequencyPenalty:     cfg.FrequencyPenalty,
+		Seed:                 cfg.Seed,
+		SystemPrompt:         cfg.SystemPrompt,
+		ReasoningEffort:      cfg.ReasoningEffort,
+		ConnectTimeout:       cfg.ConnectTimeout,
+		StreamingIdleTimeout: cfg.StreamingIdleTimeout,
+		RequestTimeout:       cfg.RequestTimeout,
+		NumCtx:               cfg.NumCtx,
+		MaxTokens:            cfg.MaxTokens,
+		StopSequences:        cfg.StopSequences,
+		ResponseSchema:       cfg.ResponseSchema,
+		ParallelToolCalls:    cfg.ParallelToolCalls,
+		UserAgent:            cfg.UserAgent,
+		Verbose:              cfg.Verbose,
+		UseStreaming:         !cfg.DisableStreaming,
+		ThinkStartTag:        cfg.ThinkStartTag,
+		ThinkEndTag:          cfg.ThinkEndTag,
 	}
 	return llm.NewClient(llmConfig)
 }
 
+// roleColors are the colors of the role labels of a pretty-printed conversation
+var roleColors = map[string]cli.Color{
+	"user":      cli.ColorBlue,
+	"assistant": cli.ColorGreen,
+	"system":    cli.ColorYellow,
+	"developer": cli.ColorYellow,
+}
+
+// colorizeRole colors a role label with the color of its role
+func colorizeRole(role, label string) string {
+	if color, ok := roleColors[role]; ok {
+		return cli.Colorize(label, color)
+	}
+	return label
+}
+
 // initMemory initializes conversation history with system message
-func initMemory(cfg *config.Config) *memory.Memory {
+func initMemory(cfg *config.Config, systemRole string) *memory.Memory {
 	// Create memory for conversation history
 	mem := memory.NewMemory()
+	mem.SetDateTimeFormatter(config.FormatCurrentDateTime)
+	mem.SetRoleColorizer(colorizeRole)
+	mem.SetSystemRole(systemRole)
 	// Initialize conversation history with system message if provided
 	if cfg.SystemPrompt != "" {
 		mem.AddSystemMessage(cfg.SystemPrompt)
@@ -172,54 +453,434 @@ func initMemory(cfg *config.Config) *memory.Memory {
 	return mem
 }
 
+// loadConversationFile restores the --conversation-file history, starting a new
+// conversation when the file does not exist yet
+func loadConversationFile(client *llm.Client, mem *memory.Memory, path string) error {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err := mem.LoadFromFile(path); err != nil {
+		return err
+	}
+	syncSystemPrompt(client, mem)
+	return nil
+}
+
+// syncSystemPrompt keeps the system prompt of a loaded conversation in line with the
+// client. The configured prompt stays in effect; the loaded one is only used without it.
+func syncSystemPrompt(client *llm.Client, mem *memory.Memory) {
+	if prompt := client.GetSystemPrompt(); prompt != "" {
+		mem.SetSystemMessage(prompt)
+	} else if prompt, ok := mem.GetSystemPrompt(); ok {
+		client.SetSystemPrompt(prompt)
+	}
+}
+
+// resumeConversation restores the messages of the --conversation-resume conversation
+// from the audit log
+func resumeConversation(cliHandler *cli.CLI, mem *memory.Memory) {
+	entries, err := auditlog.FindSession(cliHandler.GetLogFile(), cliHandler.GetResumeID())
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+	for _, entry := range entries {
+		switch entry.Role {
+		case "user":
+			mem.AddUserMessage(entry.Content)
+		case "assistant":
+			mem.AddAssistantMessage(entry.Content)
+		}
+	}
+	if !cliHandler.GetQuiet() {
+		cliHandler.ShowStatus(fmt.Sprintf("Resumed conversation %s (%d messages)", cliHandler.GetResumeID(), len(entries)))
+	}
+}
+
+// printSessionReport writes the --conversation-stats report to stderr, as JSON in JSON mode
+func printSessionReport(cliHandler *cli.CLI, report llm.SessionReport) {
+	w := cliHandler.GetErrorWriter()
+	if cliHandler.IsStructuredOutput() {
+		wrapped := map[string]llm.SessionReport{"session_report": report}
+		if cliHandler.GetOutput() == cli.OutputYAML {
+			data, err := yaml.Marshal(wrapped)
+			if err != nil {
+				cliHandler.ShowError(fmt.Errorf("error marshaling YAML: %w", err))
+				return
+			}
+			fmt.Fprintf(w, "---\n%s", data)
+			return
+		}
+		data, err := json.Marshal(wrapped)
+		if err != nil {
+			cliHandler.ShowError(err)
+			return
+		}
+		fmt.Fprintln(w, string(data))
+		return
+	}
+
+	cost := "unknown"
+	if report.EstimatedCostUSD != nil {
+		cost = fmt.Sprintf("$%.4f", *report.EstimatedCostUSD)
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nSession report")
+	fmt.Fprintf(tw, "Turns:\t%d\n", report.Turns)
+	fmt.Fprintf(tw, "Input tokens:\tmin %d | max %d | avg %.1f\n", report.InputTokens.Min, report.InputTokens.Max, report.InputTokens.Avg)
+	fmt.Fprintf(tw, "Output tokens:\tmin %d | max %d | avg %.1f\n", report.OutputTokens.Min, report.OutputTokens.Max, report.OutputTokens.Avg)
+	fmt.Fprintf(tw, "Wall-clock time:\t%s\n", cli.FormatDuration(time.Duration(report.WallClockMs)*time.Millisecond))
+	fmt.Fprintf(tw, "Thinking time:\t%.1f%%\n", report.ThinkingFraction*100)
+	fmt.Fprintf(tw, "Estimated cost:\t%s\n", cost)
+	tw.Flush()
+}
+
+// startMetricsServer serves the expvar metrics at addr in the background
+func startMetricsServer(addr string) {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           llm.MetricsHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintf(os.Stderr, "Warning: metrics server stopped: %v\n", err)
+		}
+	}()
+}
+
+// exportConversation writes the conversation to file in the given --conversation-export format
+func exportConversation(mem *memory.Memory, format, file string) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return fmt.Errorf("failed to create conversation export: %w", err)
+	}
+	if err := mem.Export(format, f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// logTurn appends the user message and the response to the --log-file audit log
+func logTurn(cliHandler *cli.CLI, message, response string) {
+	if cliHandler.GetLogFile() == "" {
+		return
+	}
+	now := time.Now()
+	id := cliHandler.GetConversationID()
+	err := auditlog.Append(cliHandler.GetLogFile(),
+		auditlog.LogEntry{ConversationID: id, Time: now, Role: "user", Content: message},
+		auditlog.LogEntry{ConversationID: id, Time: now, Role: "assistant", Content: response},
+	)
+	if err != nil {
+		cliHandler.ShowError(err)
+	}
+}
+
+// watchSystemPrompt starts watching the system prompt file when --watch-system-prompt is set
+func watchSystemPrompt(cliHandler *cli.CLI) <-chan string {
+	if !cliHandler.GetWatchSystemPrompt() {
+		return nil
+	}
+	updates, err := config.WatchSystemPrompt(cliHandler.GetSystemPromptFile(), nil)
+	if err != nil {
+		cliHandler.ShowError(err)
+		os.Exit(1)
+	}
+	return updates
+}
+
 // runConversationLoop handles the main conversation interaction
-func runConversationLoop(cliHandler *cli.CLI, client *llm.Client, mem *memory.Memory) {
+func runConversationLoop(cliHandler *cli.CLI, cfg *config.Config, client *llm.Client, mem *memory.Memory, promptUpdates <-chan string) {
+	startTag, endTag := client.GetThinkingTags()
 	for {
 		message, shouldExit := handleUserInput(cliHandler)
 		if shouldExit {
-			if !cliHandler.GetJSON() {
-				client.DisplayTotalUsage()
+			if !cliHandler.IsStructuredOutput() && !cliHandler.GetQuiet() && !cfg.DisableTotalUsageOnExit {
+				client.DisplayTotalUsage(cliHandler.GetWriter())
 			}
 			return
 		}
 
 		// Skip empty messages
 		if !cliHandler.IsValidMessage(message) {
+			// Stdin is already drained in non-interactive mode
+			if !cliHandler.IsInteractive() {
+				return
+			}
+			continue
+		}
+
+		if cliHandler.IsInteractive() && handleCommand(cliHandler, client, mem, message) {
+			continue
+		}
+
+		// Apply the latest system prompt if the file changed
+		select {
+		case prompt := <-promptUpdates:
+			client.SetSystemPrompt(prompt)
+			mem.SetSystemPrompt(prompt)
+			cliHandler.ShowStatus("System prompt reloaded")
+		default:
+		}
+
+		message = decorateMessage(cliHandler, message)
+		message, err := applyMessageTemplate(cfg.MessageTemplate, message)
+		if err != nil {
+			cliHandler.ShowError(err)
+			if !cliHandler.IsInteractive() {
+				break
+			}
+			continue
+		}
+		if !confirmCost(cliHandler, cfg, client, mem, message) {
 			continue
 		}
 
 		// Add user message to history
 		mem.AddUserMessage(message)
 
-		response, err := processResponse(cliHandler, client, mem)
+		response, err := processResponse(cliHandler, cfg, client, mem, message)
+		if errors.Is(err, context.Canceled) {
+			// Keep the partial response so the conversation can go on
+			if !cliHandler.IsStructuredOutput() {
+				fmt.Fprintln(cliHandler.GetWriter(), "\n"+interruptedSuffix)
+			}
+			response = strings.TrimSpace(response + "\n" + interruptedSuffix)
+			err = nil
+		}
 		if err != nil {
+			if errors.Is(err, context.DeadlineExceeded) {
+				err = fmt.Errorf("%w (increase the limit with --timeout)", err)
+			}
 			cliHandler.ShowError(err)
-			// Exit on error in non-interactive (JSON) mode
-			if cliHandler.GetJSON() {
+			// Exit on error in non-interactive mode
+			if !cliHandler.IsInteractive() {
 				break
 			}
 			continue
 		}
 
-		displayResults(cliHandler, client, response)
+		// Thinking blocks bypass the response filter
+		thinking, plain := llm.SplitThinkingBlocks(response, startTag, endTag)
+		if len(thinking) == 0 {
+			thinking, plain = []string{}, response
+		}
+		if cfg.ResponseFilter != nil {
+			plain = cfg.ResponseFilter(plain)
+		}
 
-		// Add assistant response to history (without thinking blocks)
-		mem.AddAssistantMessage(removeThinkingBlocks(response))
+		displayResults(cliHandler, client, plain, thinking)
 
-		// Exit after one response in non-interactive (JSON) mode
-		if cliHandler.GetJSON() && err == nil {
+		// Add assistant response to history, keeping the thinking blocks apart so they
+		// are exported but not sent back to the model
+		mem.AddAssistantMessageWithThinking(plain, strings.Join(thinking, "\n\n"))
+		logTurn(cliHandler, message, plain)
+
+		// Exit after one response in non-interactive mode
+		if !cliHandler.IsInteractive() {
 			break
 		}
 	}
 }
 
+// commandHelp is printed by the /help command
+const commandHelp = `Commands:
+  /help           Show this help
+  /quit           Exit the conversation
+  /clear          Forget the conversation so far, keeping the system prompt
+  /export [file]  Write the conversation as Markdown (default: the --export-md file)
+  /save <file>    Save the conversation as JSON, in the --conversation-file format
+  /load <file>    Replace the conversation with one saved by /save
+  /model [name]   Switch to another model, or show the current one
+  /stats          Show the token usage and time of the session so far
+  /system [text]  Replace the system prompt, or show the current one`
+
+// handleCommand runs the in-conversation command in message and reports whether it was
+// handled. Unknown commands are sent to the model as regular messages.
+func handleCommand(cliHandler *cli.CLI, client *llm.Client, mem *memory.Memory, message string) bool {
+	name, arg, ok := cliHandler.ParseCommand(message)
+	if !ok {
+		return false
+	}
+
+	switch name {
+	case "export":
+		path := arg
+		if path == "" {
+			path = cliHandler.GetExportMarkdown()
+		}
+		if path == "" {
+			cliHandler.ShowError(errors.New("usage: /export <file> (or set --export-md)"))
+			return true
+		}
+		if err := exportConversation(mem, "markdown", path); err != nil {
+			cliHandler.ShowError(err)
+			return true
+		}
+		cliHandler.ShowStatus(fmt.Sprintf("Conversation exported to %s", path))
+	case "clear":
+		// Start over with the current system prompt, including reloaded ones
+		mem.Clear()
+		if prompt := client.GetSystemPrompt(); prompt != "" {
+			mem.AddSystemMessage(prompt)
+		}
+		cliHandler.ShowStatus("[Conversation cleared]")
+	case "save":
+		if arg == "" {
+			cliHandler.ShowError(errors.New("usage: /save <file>"))
+			return true
+		}
+		if err := mem.SaveToFile(arg); err != nil {
+			cliHandler.ShowError(err)
+			return true
+		}
+		cliHandler.ShowStatus(fmt.Sprintf("Conversation saved to %s", arg))
+	case "load":
+		if arg == "" {
+			cliHandler.ShowError(errors.New("usage: /load <file>"))
+			return true
+		}
+		if err := mem.LoadFromFile(arg); err != nil {
+			cliHandler.ShowError(err)
+			return true
+		}
+		syncSystemPrompt(client, mem)
+		cliHandler.ShowStatus(fmt.Sprintf("Loaded %d messages from %s", mem.Len(), arg))
+		showLastAssistantMessage(cliHandler, mem)
+	case "model":
+		switchModel(cliHandler, client, mem, arg)
+	case "stats":
+		showSessionStats(cliHandler, client, mem)
+	case "system":
+		if arg == "" {
+			fmt.Fprintf(cliHandler.GetWriter(), "System prompt:\n%s\n", client.GetSystemPrompt())
+			return true
+		}
+		client.SetSystemPrompt(arg)
+		mem.SetSystemMessage(arg)
+		cliHandler.ShowStatus("[System prompt updated]")
+	case "help":
+		fmt.Fprintln(cliHandler.GetWriter(), commandHelp)
+	default:
+		return false
+	}
+	return true
+}
+
+// switchModel makes the /model command switch to the named model after checking that
+// the Ollama server has it, or shows the current model without a name. The system prompt
+// takes the role expected by the new model.
+func switchModel(cliHandler *cli.CLI, client *llm.Client, mem *memory.Memory, model string) {
+	if model == "" {
+		cliHandler.ShowStatus(fmt.Sprintf("Current model: %s", client.GetCurrentModel()))
+		return
+	}
+
+	exists, err := client.CheckModelExists(model)
+	switch {
+	case errors.Is(err, llm.ErrOllamaAPIUnavailable):
+		// Other providers validate the model with the next request
+	case err != nil:
+		cliHandler.ShowStatus(fmt.Sprintf("Warning: could not check model '%s': %v", model, err))
+	case !exists:
+		cliHandler.ShowError(fmt.Errorf("model '%s' not found on Ollama server", model))
+		return
+	}
+	client.SetModel(model)
+	mem.SetSystemRole(llm.DefaultSystemPromptRole(model))
+	cliHandler.ShowStatus(fmt.Sprintf("[Model switched to %s]", model))
+}
+
+// showSessionStats prints the cumulative token usage and time and the size of the
+// conversation for the /stats command
+func showSessionStats(cliHandler *cli.CLI, client *llm.Client, mem *memory.Memory) {
+	client.DisplayTotalUsage(cliHandler.GetWriter())
+	total := client.GetTotalStats()
+	report := client.GetSessionReport()
+	w := cliHandler.GetWriter()
+	fmt.Fprintf(w, "Total time: Thinking %s | Response %s | Total %s\n",
+		cli.FormatDuration(total.ThinkingTime),
+		cli.FormatDuration(total.ResponseTime),
+		cli.FormatDuration(total.ThinkingTime+total.ResponseTime))
+	if report.Turns > 0 {
+		fmt.Fprintf(w, "Average per turn: Input %.1f | Output %.1f tokens (%d turns)\n",
+			report.InputTokens.Avg, report.OutputTokens.Avg, report.Turns)
+	}
+	fmt.Fprintf(w, "Words: %d | Characters: %d\n", mem.WordCount(), mem.CharCount())
+}
+
+// showLastAssistantMessage prints the latest response of the conversation, giving
+// context after /load
+func showLastAssistantMessage(cliHandler *cli.CLI, mem *memory.Memory) {
+	messages := mem.GetMessages()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].OfAssistant == nil {
+			continue
+		}
+		text, err := llm.MessageText(messages[i])
+		if err != nil {
+			return
+		}
+		fmt.Fprintf(cliHandler.GetWriter(), "\nLast response:\n%s\n", text)
+		return
+	}
+}
+
+// decorateMessage adds the --prepend-prefix and --append-suffix texts to a user message
+func decorateMessage(cliHandler *cli.CLI, message string) string {
+	if prefix := cliHandler.GetPrependPrefix(); prefix != "" {
+		message = prefix + "\n" + message
+	}
+	if suffix := cliHandler.GetAppendSuffix(); suffix != "" {
+		message = message + "\n" + suffix
+	}
+	return message
+}
+
+// applyMessageTemplate renders the --template file with the user message as {{.Message}}
+func applyMessageTemplate(tmpl *template.Template, message string) (string, error) {
+	if tmpl == nil {
+		return message, nil
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, struct{ Message string }{message}); err != nil {
+		return "", fmt.Errorf("failed to render message template: %w", err)
+	}
+	return rendered.String(), nil
+}
+
+// confirmCost asks whether to send the message when its estimated cost exceeds the
+// configured threshold. Requests for models without a known price are always sent.
+func confirmCost(cliHandler *cli.CLI, cfg *config.Config, client *llm.Client, mem *memory.Memory, message string) bool {
+	if cfg.CostWarningThreshold <= 0 || !cliHandler.IsInteractive() {
+		return true
+	}
+
+	messages := append(append([]openai.ChatCompletionMessageParamUnion(nil), mem.GetMessages()...), openai.UserMessage(message))
+	cost, err := client.EstimateRequestCost(messages)
+	if err != nil || cost <= cfg.CostWarningThreshold {
+		return true
+	}
+
+	confirmed, err := cliHandler.Confirm(fmt.Sprintf("Estimated cost: $%.2f. Continue?", cost))
+	if err != nil {
+		cliHandler.ShowError(err)
+		return false
+	}
+	return confirmed
+}
+
 // handleUserInput gets and validates user input
 func handleUserInput(cliHandler *cli.CLI) (string, bool) {
 	// Get user input
 	var err error
 	var message string
-	if !cliHandler.GetJSON() {
-		fmt.Print("\nEnter your message (or '/quit' to exit): ")
+	if cliHandler.IsInteractive() {
+		if !cliHandler.GetQuiet() {
+			cliHandler.ShowPrompt()
+		}
 		message, err = cliHandler.GetUserInput()
 	} else {
 		message, err = cliHandler.ReadFromStdin()
@@ -241,52 +902,166 @@ func handleUserInput(cliHandler *cli.CLI) (string, bool) {
 	return message, false
 }
 
-// processResponse handles streaming and processing of LLM responses
-func processResponse(cliHandler *cli.CLI, client *llm.Client, mem *memory.Memory) (string, error) {
+// interruptedSuffix marks a response cut short with Ctrl+C
+const interruptedSuffix = "[interrupted]"
+
+// processResponse handles streaming and processing of LLM responses. Ctrl+C stops the
+// response and returns the part received so far with an error wrapping context.Canceled.
+func processResponse(cliHandler *cli.CLI, cfg *config.Config, client *llm.Client, mem *memory.Memory, message string) (string, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
 	// Send message and stream response
-	chunkChan := make(chan string)
+	chunkChan := make(chan string, cfg.StreamBufferSize)
 	resultChan := make(chan struct {
 		response string
 		err      error
 	}, 1)
 
-	// Only show "Response:" header in non-JSON mode
-	if !cliHandler.GetJSON() {
-		fmt.Println("\nResponse:")
+	// Only show "Response:" header in non-JSON, non-quiet mode
+	if !cliHandler.IsStructuredOutput() && !cliHandler.GetQuiet() {
+		fmt.Fprintln(cliHandler.GetWriter(), "\nResponse:")
 	}
 
 	// Start streaming in a goroutine
 	go func() {
-		response, err := client.StreamResponse(mem.GetMessages(), cliHandler.GetHideThinking(), chunkChan)
+		var response string
+		var err error
+		if cliHandler.GetRawCompletion() {
+			// Raw completion sends only the latest message, without chat formatting
+			response, err = client.GenerateRaw(ctx, message, func(chunk string) {
+				chunkChan <- chunk
+			})
+			close(chunkChan)
+		} else if !client.GetCurrentConfig().UseStreaming {
+			// The whole response is displayed at once
+			response, err = client.GetResponseContext(ctx, mem.GetMessages(), cliHandler.GetHideThinking())
+			if err == nil {
+				chunkChan <- response
+			}
+			close(chunkChan)
+		} else {
+			response, err = client.StreamResponseContext(ctx, mem.GetMessages(), cliHandler.GetHideThinking(), chunkChan)
+		}
 		resultChan <- struct {
 			response string
 			err      error
 		}{response: response, err: err}
 	}()
 
-	// Print chunks as they arrive (only in non-JSON mode)
-	for chunk := range chunkChan {
-		if !cliHandler.GetJSON() {
-			fmt.Print(chunk)
-		}
+	// Throttle display without slowing down the stream itself
+	displayChan := (<-chan string)(chunkChan)
+	if cliHandler.GetStreamDelay() > 0 && !cliHandler.IsStructuredOutput() {
+		displayChan = relayChunks(chunkChan)
 	}
 
+	displayChunks(cliHandler, displayChan)
+
 	// Wait for streaming to complete and get result
 	result := <-resultChan
+	if result.err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		return result.response, fmt.Errorf("response interrupted: %w", context.Canceled)
+	}
 	return result.response, result.err
 }
 
-// displayResults formats and displays the response based on output mode
-func displayResults(cliHandler *cli.CLI, client *llm.Client, response string) {
-	if !cliHandler.GetJSON() {
-		client.DisplayTokenUsage()
+// displayChunks prints chunks as they arrive (only in non-JSON mode), applying the
+// configured stream delay and line limit
+func displayChunks(cliHandler *cli.CLI, chunks <-chan string) {
+	out := cliHandler.GetWriter()
+	streamDelay := cliHandler.GetStreamDelay()
+	maxLines := cliHandler.GetMaxResponseLines()
+	lines := 0
+	truncated := false
+
+	for chunk := range chunks {
+		// Keep draining after truncation so the stream can complete
+		if cliHandler.IsStructuredOutput() || truncated {
+			continue
+		}
+		if maxLines > 0 {
+			chunk, truncated = truncateLines(chunk, maxLines, &lines)
+		}
+		fmt.Fprint(out, chunk)
+		if truncated {
+			fmt.Fprintf(out, "\n[Output truncated at %d lines — full response in memory]\n", maxLines)
+		}
+		if streamDelay > 0 {
+			time.Sleep(streamDelay)
+		}
+	}
+}
+
+// truncateLines returns the part of chunk that fits within maxLines, given the number of
+// lines already displayed, and whether the limit was reached
+func truncateLines(chunk string, maxLines int, lines *int) (string, bool) {
+	for i, r := range chunk {
+		if r != '\n' {
+			continue
+		}
+		*lines++
+		if *lines >= maxLines {
+			return chunk[:i], true
+		}
+	}
+	return chunk, false
+}
+
+// countThinkingWords returns the number of words in the thinking blocks
+func countThinkingWords(thinking []string) int {
+	words := 0
+	for _, block := range thinking {
+		words += len(strings.Fields(block))
+	}
+	return words
+}
+
+// relayChunks forwards chunks through an unbounded queue so the sender never blocks
+// on a slow consumer
+func relayChunks(in <-chan string) <-chan string {
+	out := make(chan string)
+	go func() {
+		defer close(out)
+		var pending []string
+		for in != nil || len(pending) > 0 {
+			var send chan<- string
+			var next string
+			if len(pending) > 0 {
+				send = out
+				next = pending[0]
+			}
+			select {
+			case chunk, ok := <-in:
+				if !ok {
+					in = nil
+					continue
+				}
+				pending = append(pending, chunk)
+			case send <- next:
+				pending = pending[1:]
+			}
+		}
+	}()
+	return out
+}
+
+// displayResults formats and displays the response based on output mode, with one
+// thinking entry per thinking block in JSON and YAML output
+func displayResults(cliHandler *cli.CLI, client *llm.Client, response string, thinking []string) {
+	if !cliHandler.IsStructuredOutput() {
+		if !cliHandler.GetQuiet() && !cliHandler.GetNoTokenUsage() {
+			client.DisplayTokenUsage(cliHandler.GetWriter())
+		}
 		return
 	}
-	// Handle JSON output if requested
+	// Handle JSON or YAML output if requested
 	stats := client.GetStats()
 	jsonResponse := map[string]interface{}{
-		"response": removeThinkingBlocks(response),
-		"thinking": extractThinkingBlocks(response),
+		"conversation_id": cliHandler.GetConversationID(),
+		"response":        response,
+		"thinking":        thinking,
+		// Identifies the backend configuration, for reproducibility with --seed
+		"system_fingerprint": stats.SystemFingerprint,
 		"stats": map[string]interface{}{
 			"tokens": map[string]int{
 				"input":  stats.InputTokens,
@@ -294,17 +1069,33 @@ func displayResults(cliHandler *cli.CLI, client *llm.Client, response string) {
 				"total":  stats.InputTokens + stats.OutputTokens,
 			},
 			"time": map[string]int64{
-				"thinking_ms": stats.ThinkingTime.Milliseconds(),
-				"response_ms": stats.ResponseTime.Milliseconds(),
-				"total_ms":    (stats.ThinkingTime + stats.ResponseTime).Milliseconds(),
+				"thinking_ms":    stats.ThinkingTime.Milliseconds(),
+				"response_ms":    stats.ResponseTime.Milliseconds(),
+				"total_ms":       (stats.ThinkingTime + stats.ResponseTime).Milliseconds(),
+				"thinking_words": int64(countThinkingWords(thinking)),
 			},
+			"tokens_per_second": stats.OutputTokensPerSecond,
 		},
 	}
+	if cliHandler.GetIncludeUsage() {
+		jsonResponse["usage_timeline"] = client.GetUsageTimeline()
+	}
+
+	if cliHandler.GetOutput() == cli.OutputYAML {
+		// Separate the responses of a conversation as YAML documents
+		yamlData, err := yaml.Marshal(jsonResponse)
+		if err != nil {
+			cliHandler.ShowError(fmt.Errorf("error marshaling YAML: %w", err))
+			return
+		}
+		fmt.Fprintf(cliHandler.GetWriter(), "---\n%s", yamlData)
+		return
+	}
 
 	jsonData, err := json.Marshal(jsonResponse)
 	if err != nil {
 		cliHandler.ShowError(fmt.Errorf("error marshaling JSON: %w", err))
 		return
 	}
-	fmt.Println(string(jsonData))
+	fmt.Fprintln(cliHandler.GetWriter(), string(jsonData))
 }